@@ -0,0 +1,55 @@
+package container
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/dsnet/compress/bzip2"
+)
+
+// The compression level used by the Bzip2 codec
+const DefaultBzip2Level = bzip2.DefaultCompression
+
+type bzip2Codec struct {
+	level int
+}
+
+// Create a bzip2 codec with the given compression level, from bzip2.BestSpeed to bzip2.BestCompression.
+// Invalid levels are reported by Compress.
+func NewBzip2Codec(level int) Codec {
+	return &bzip2Codec{
+		level: level,
+	}
+}
+
+func (b *bzip2Codec) Name() string {
+	return "bzip2"
+}
+
+func (b *bzip2Codec) Compress(block []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := bzip2.NewWriter(&buf, &bzip2.WriterConfig{Level: b.level})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = writer.Write(block)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (b *bzip2Codec) Decompress(block []byte) ([]byte, error) {
+	reader, err := bzip2.NewReader(bytes.NewReader(block), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
@@ -0,0 +1,76 @@
+package container
+
+import (
+	"sync"
+)
+
+// A Codec specifies how the blocks within a container file should be compressed.
+// Codecs must be safe for concurrent use.
+type Codec interface {
+	// The name written to the `avro.codec` key of the file header, which identifies the codec to readers
+	Name() string
+	// Compress the serialized records of a single block
+	Compress(block []byte) ([]byte, error)
+	// Decompress the contents of a single block, returning the serialized records
+	Decompress(block []byte) ([]byte, error)
+}
+
+var (
+	// No compression
+	Null Codec = nullCodec{}
+	// Deflate compression, with the default compression level
+	Deflate Codec = NewDeflateCodec(DefaultDeflateLevel)
+	// Snappy compression
+	Snappy Codec = snappyCodec{}
+	// Zstandard compression, with the default compression level
+	Zstandard Codec = NewZstandardCodec(DefaultZstandardLevel)
+	// Bzip2 compression, with the default compression level
+	Bzip2 Codec = NewBzip2Codec(DefaultBzip2Level)
+	// XZ compression
+	XZ Codec = xzCodec{}
+)
+
+var (
+	codecsLock sync.RWMutex
+	codecs     = map[string]Codec{
+		Null.Name():      Null,
+		Deflate.Name():   Deflate,
+		Snappy.Name():    Snappy,
+		Zstandard.Name(): Zstandard,
+		Bzip2.Name():     Bzip2,
+		XZ.Name():        XZ,
+	}
+)
+
+// Register a codec so it can be found by name with LookupCodec, which is how Readers find the codec named in
+// a file header. Registering a codec with the same name as an existing codec replaces it.
+func RegisterCodec(codec Codec) {
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+	codecs[codec.Name()] = codec
+}
+
+// Find a registered codec by name. Returns an UnsupportedCodecError if there is no codec with that name.
+func LookupCodec(name string) (Codec, error) {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, NewUnsupportedCodecError(name)
+	}
+	return codec, nil
+}
+
+type nullCodec struct{}
+
+func (nullCodec) Name() string {
+	return "null"
+}
+
+func (nullCodec) Compress(block []byte) ([]byte, error) {
+	return block, nil
+}
+
+func (nullCodec) Decompress(block []byte) ([]byte, error) {
+	return block, nil
+}
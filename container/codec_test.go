@@ -0,0 +1,86 @@
+package container
+
+import (
+	"bytes"
+	"compress/flate"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Round-trip records through the writer and reader with each codec
+
+func readerRoundTripWithCodec(codec Codec, t *testing.T) {
+	fileBytes := writeRangeContainer(0, 10, codec, 2, t)
+
+	reader, err := NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, codec, reader.Codec())
+	assert.Equal(t, longRecord(0).Schema(), reader.Schema())
+
+	checkRangeContainer(fileBytes, 0, 10, t)
+}
+
+func TestReaderNullEncoding(t *testing.T) {
+	readerRoundTripWithCodec(Null, t)
+}
+
+func TestReaderDeflateEncoding(t *testing.T) {
+	readerRoundTripWithCodec(Deflate, t)
+}
+
+func TestReaderSnappyEncoding(t *testing.T) {
+	readerRoundTripWithCodec(Snappy, t)
+}
+
+func TestReaderZstandardEncoding(t *testing.T) {
+	readerRoundTripWithCodec(Zstandard, t)
+}
+
+func TestReaderBzip2Encoding(t *testing.T) {
+	readerRoundTripWithCodec(Bzip2, t)
+}
+
+func TestReaderXZEncoding(t *testing.T) {
+	readerRoundTripWithCodec(XZ, t)
+}
+
+func TestReaderDeflateLevel(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 10, NewDeflateCodec(flate.BestCompression), 2, t)
+	checkRangeContainer(fileBytes, 0, 10, t)
+}
+
+// A codec which reverses the bytes of each block, to test codec registration
+type reverseCodec struct{}
+
+func (reverseCodec) Name() string {
+	return "reverse"
+}
+
+func (reverseCodec) Compress(block []byte) ([]byte, error) {
+	reversed := make([]byte, len(block))
+	for i, b := range block {
+		reversed[len(block)-1-i] = b
+	}
+	return reversed, nil
+}
+
+func (c reverseCodec) Decompress(block []byte) ([]byte, error) {
+	return c.Compress(block)
+}
+
+func TestRegisteredCodec(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 10, reverseCodec{}, 2, t)
+
+	_, err := NewReader(bytes.NewReader(fileBytes))
+	assert.IsType(t, &UnsupportedCodecError{}, err)
+
+	RegisterCodec(reverseCodec{})
+	codec, err := LookupCodec("reverse")
+	assert.Nil(t, err)
+	assert.Equal(t, reverseCodec{}, codec)
+
+	readerRoundTripWithCodec(reverseCodec{}, t)
+}
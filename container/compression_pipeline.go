@@ -0,0 +1,142 @@
+package container
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/actgardner/gogen-avro/container/avro"
+)
+
+// A block which has been handed to the compression pipeline
+type pendingBlock struct {
+	avro.AvroContainerBlock
+	stats BlockStats
+	err   error
+	// Closed once the block has been compressed
+	compressed chan struct{}
+}
+
+// compressionPipeline compresses blocks on a pool of goroutines, and writes them to the
+// underlying io.Writer in the order they were submitted. The goroutines are started when
+// the first block is submitted, and stopped by drain.
+type compressionPipeline struct {
+	writer      io.Writer
+	codec       Codec
+	workers     int
+	maxInFlight int
+	observer    WriterObserver
+
+	running bool
+	blocks  chan *pendingBlock
+	ordered chan *pendingBlock
+	done    chan struct{}
+
+	errLock sync.Mutex
+	err     error
+}
+
+func newCompressionPipeline(writer io.Writer, codec Codec, workers, maxInFlight int) *compressionPipeline {
+	return &compressionPipeline{
+		writer:      writer,
+		codec:       codec,
+		workers:     workers,
+		maxInFlight: maxInFlight,
+	}
+}
+
+func (p *compressionPipeline) start() {
+	p.blocks = make(chan *pendingBlock, p.maxInFlight)
+	p.ordered = make(chan *pendingBlock, p.maxInFlight)
+	p.done = make(chan struct{})
+	for i := 0; i < p.workers; i++ {
+		go p.compress(p.blocks)
+	}
+	go p.write(p.ordered, p.done)
+	p.running = true
+}
+
+// Hand a block to the pipeline to be compressed and written. Blocks the caller while
+// maxInFlight blocks are waiting to be written. Returns the first error from any block
+// submitted previously, in which case the block isn't written.
+func (p *compressionPipeline) submit(block *avro.AvroContainerBlock) error {
+	if err := p.firstError(); err != nil {
+		return err
+	}
+
+	if !p.running {
+		p.start()
+	}
+
+	pending := &pendingBlock{
+		AvroContainerBlock: *block,
+		compressed:         make(chan struct{}),
+	}
+	p.ordered <- pending
+	p.blocks <- pending
+	return nil
+}
+
+// Wait for every submitted block to be written, stop the goroutines and return the first error
+func (p *compressionPipeline) drain() error {
+	if p.running {
+		close(p.blocks)
+		close(p.ordered)
+		<-p.done
+		p.running = false
+	}
+	return p.firstError()
+}
+
+func (p *compressionPipeline) compress(blocks <-chan *pendingBlock) {
+	for block := range blocks {
+		block.stats.Records = block.NumRecords
+		block.stats.UncompressedBytes = len(block.RecordBytes)
+		compressStart := time.Now()
+		block.RecordBytes, block.err = p.codec.Compress(block.RecordBytes)
+		block.stats.CompressTime = time.Since(compressStart)
+		block.stats.CompressedBytes = len(block.RecordBytes)
+		close(block.compressed)
+	}
+}
+
+func (p *compressionPipeline) write(ordered <-chan *pendingBlock, done chan<- struct{}) {
+	defer close(done)
+	for block := range ordered {
+		<-block.compressed
+		// Once a block has failed, later blocks are discarded so the file never has a gap
+		if err := p.firstError(); err != nil {
+			block.stats.Err = err
+			p.blockWritten(block.stats)
+			continue
+		}
+
+		err := block.err
+		if err == nil {
+			writeStart := time.Now()
+			err = block.Serialize(p.writer)
+			block.stats.WriteTime = time.Since(writeStart)
+		}
+
+		if err != nil {
+			p.errLock.Lock()
+			p.err = err
+			p.errLock.Unlock()
+		}
+
+		block.stats.Err = err
+		p.blockWritten(block.stats)
+	}
+}
+
+func (p *compressionPipeline) firstError() error {
+	p.errLock.Lock()
+	defer p.errLock.Unlock()
+	return p.err
+}
+
+func (p *compressionPipeline) blockWritten(stats BlockStats) {
+	if p.observer != nil {
+		p.observer.BlockWritten(stats)
+	}
+}
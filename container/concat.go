@@ -0,0 +1,57 @@
+package container
+
+import (
+	"io"
+)
+
+// Concatenate container files into a single file written to w, copying the compressed blocks without decoding them.
+// The output has the schema, codec and user metadata of the first input, and a new sync marker unless one is passed
+// with WithSyncMarker. The inputs are read one after another, and each input's schema and codec are checked against the
+// first input before its blocks are copied. Errors caused by an input, including a SchemaMismatchError or
+// CodecMismatchError, are returned as an InputError, in which case the output is incomplete.
+func Concat(w io.Writer, inputs []io.Reader, opts ...WriterOption) error {
+	var first *Reader
+	var writer *Writer
+	for i, input := range inputs {
+		reader, err := NewReader(input)
+		if err != nil {
+			return NewInputError(i, err)
+		}
+
+		if first == nil {
+			first = reader
+			opts = append([]WriterOption{WithMetadata(reader.UserMetadata())}, opts...)
+			writer, err = NewWriter(w, reader.Codec(), 1, reader.Schema(), opts...)
+			if err != nil {
+				return err
+			}
+		} else {
+			if err = reader.CheckSchema(first.Schema()); err != nil {
+				return NewInputError(i, err)
+			}
+
+			if reader.Codec().Name() != first.Codec().Name() {
+				return NewInputError(i, NewCodecMismatchError(first.Codec().Name(), reader.Codec().Name()))
+			}
+		}
+
+		for {
+			block, err := reader.NextBlock()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return NewInputError(i, err)
+			}
+
+			if err = writer.WriteBlock(block); err != nil {
+				return err
+			}
+		}
+	}
+
+	if writer == nil {
+		return nil
+	}
+	return writer.Close()
+}
@@ -0,0 +1,106 @@
+package container
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcat(t *testing.T) {
+	inputs := []io.Reader{
+		bytes.NewReader(writeRangeContainer(0, 10, Deflate, 3, t)),
+		bytes.NewReader(writeRangeContainer(10, 0, Deflate, 3, t)),
+		bytes.NewReader(writeRangeContainer(10, 25, Deflate, 3, t)),
+	}
+
+	var buf bytes.Buffer
+	err := Concat(&buf, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Verify(bytes.NewReader(buf.Bytes()), decodeLongRecord)
+	assert.Nil(t, err)
+	assert.Equal(t, &VerifyReport{Blocks: 4 + 9, Records: 35}, report)
+
+	checkRangeContainer(buf.Bytes(), 0, 35, t)
+}
+
+func TestConcatMetadata(t *testing.T) {
+	inputs := []io.Reader{
+		bytes.NewReader(writeRangeContainer(0, 10, Deflate, 3, t, WithMetadata(map[string][]byte{"producer": []byte("test")}))),
+		bytes.NewReader(writeRangeContainer(10, 10, Deflate, 3, t)),
+	}
+
+	syncMarker := [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	var buf bytes.Buffer
+	err := Concat(&buf, inputs, WithSyncMarker(syncMarker))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string][]byte{"producer": []byte("test")}, reader.UserMetadata())
+	assert.Equal(t, Deflate.Name(), reader.Codec().Name())
+	assert.Equal(t, syncMarker, [16]byte(reader.SyncMarker()))
+}
+
+func TestConcatSchemaMismatch(t *testing.T) {
+	var other bytes.Buffer
+	otherWriter, err := NewWriter(&other, Deflate, 10, `"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, otherWriter.Close())
+
+	inputs := []io.Reader{
+		bytes.NewReader(writeRangeContainer(0, 10, Deflate, 3, t)),
+		&other,
+	}
+
+	err = Concat(ioutil.Discard, inputs)
+	inputErr, ok := err.(*InputError)
+	assert.True(t, ok)
+	assert.Equal(t, 1, inputErr.Input)
+	assert.IsType(t, &SchemaMismatchError{}, inputErr.Err)
+}
+
+func TestConcatEquivalentSchema(t *testing.T) {
+	// Schemas are compared by their canonical form, like records passed to WriteRecord
+	var inputs []io.Reader
+	for _, schemaJson := range []string{`"string"`, `{"type": "string", "doc": "Equivalent"}`} {
+		var buf bytes.Buffer
+		writer, err := NewWriter(&buf, Null, 10, schemaJson)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, writer.Close())
+		inputs = append(inputs, &buf)
+	}
+
+	var output bytes.Buffer
+	assert.Nil(t, Concat(&output, inputs))
+
+	reader, err := NewReader(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `"string"`, reader.Schema())
+}
+
+func TestConcatCodecMismatch(t *testing.T) {
+	inputs := []io.Reader{
+		bytes.NewReader(writeRangeContainer(0, 10, Deflate, 3, t)),
+		bytes.NewReader(writeRangeContainer(0, 10, Deflate, 3, t)),
+		bytes.NewReader(writeRangeContainer(0, 10, Snappy, 3, t)),
+	}
+
+	err := Concat(ioutil.Discard, inputs)
+	assert.Equal(t, NewInputError(2, NewCodecMismatchError("deflate", "snappy")), err)
+}
@@ -0,0 +1,62 @@
+package container
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+	"sync"
+)
+
+// The compression level used by the Deflate codec
+const DefaultDeflateLevel = flate.DefaultCompression
+
+type deflateCodec struct {
+	level   int
+	writers *sync.Pool
+}
+
+// Create a deflate codec with the given compression level, from flate.HuffmanOnly to flate.BestCompression.
+// Invalid levels are reported by Compress.
+func NewDeflateCodec(level int) Codec {
+	return &deflateCodec{
+		level:   level,
+		writers: &sync.Pool{},
+	}
+}
+
+func (d *deflateCodec) Name() string {
+	return "deflate"
+}
+
+func (d *deflateCodec) Compress(block []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	// flate.Writers allocate a lot of state, so reuse them between blocks
+	writer, ok := d.writers.Get().(*flate.Writer)
+	if ok {
+		writer.Reset(&buf)
+	} else {
+		writer, err = flate.NewWriter(&buf, d.level)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer d.writers.Put(writer)
+
+	_, err = writer.Write(block)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *deflateCodec) Decompress(block []byte) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(block))
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
@@ -0,0 +1,194 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/actgardner/gogen-avro/container/avro"
+)
+
+// ErrInvalidMagic is returned when a file does not start with the Avro OCF magic bytes
+var ErrInvalidMagic = errors.New("Invalid magic bytes, not an Avro object container file")
+
+// ErrWriterClosed is returned when a Writer is used after Close has been called
+var ErrWriterClosed = errors.New("Writer is closed")
+
+// SyncMarkerError is returned when a block is not followed by the sync marker from the file header
+type SyncMarkerError struct {
+	Expected avro.Sync
+	Actual   avro.Sync
+}
+
+func NewSyncMarkerError(expected, actual avro.Sync) *SyncMarkerError {
+	return &SyncMarkerError{
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+func (s *SyncMarkerError) Error() string {
+	return fmt.Sprintf("Invalid sync marker after block: expected %x, got %x", s.Expected, s.Actual)
+}
+
+// UnsupportedCodecError is returned when a file header names a codec this package can't decompress
+type UnsupportedCodecError struct {
+	Codec string
+}
+
+func NewUnsupportedCodecError(codec string) *UnsupportedCodecError {
+	return &UnsupportedCodecError{
+		Codec: codec,
+	}
+}
+
+func (u *UnsupportedCodecError) Error() string {
+	return fmt.Sprintf("Unsupported codec %q", u.Codec)
+}
+
+// ChecksumError is returned when the checksum of a decompressed block doesn't match the checksum stored with the block
+type ChecksumError struct {
+	Expected uint32
+	Actual   uint32
+}
+
+func NewChecksumError(expected, actual uint32) *ChecksumError {
+	return &ChecksumError{
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+func (c *ChecksumError) Error() string {
+	return fmt.Sprintf("Block checksum mismatch: expected %08x, got %08x", c.Expected, c.Actual)
+}
+
+// SchemaMismatchError is returned when the schema of a file doesn't match the schema of the records being read or written
+type SchemaMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func NewSchemaMismatchError(expected, actual string) *SchemaMismatchError {
+	return &SchemaMismatchError{
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+func (s *SchemaMismatchError) Error() string {
+	return fmt.Sprintf("Schema mismatch: expected %v, got %v", s.Expected, s.Actual)
+}
+
+// ReservedMetadataKeyError is returned when user metadata uses a key in the reserved `avro.` namespace
+type ReservedMetadataKeyError struct {
+	Key string
+}
+
+func NewReservedMetadataKeyError(key string) *ReservedMetadataKeyError {
+	return &ReservedMetadataKeyError{
+		Key: key,
+	}
+}
+
+func (r *ReservedMetadataKeyError) Error() string {
+	return fmt.Sprintf("Metadata key %q is in the reserved avro. namespace", r.Key)
+}
+
+// RecordCountError is returned when the data in a block doesn't hold the number of records in the block header
+type RecordCountError struct {
+	// The number of records in the block header
+	Records int64
+	// The number of records decoded successfully
+	Decoded int64
+	// The number of bytes left after decoding every record
+	Remaining int
+	// The error decoding the next record, if there was one
+	Err error
+}
+
+func NewRecordCountError(records, decoded int64, remaining int, err error) *RecordCountError {
+	return &RecordCountError{
+		Records:   records,
+		Decoded:   decoded,
+		Remaining: remaining,
+		Err:       err,
+	}
+}
+
+func (r *RecordCountError) Error() string {
+	if r.Err != nil {
+		return fmt.Sprintf("Block has %v records, but only %v could be decoded: %v", r.Records, r.Decoded, r.Err)
+	}
+	return fmt.Sprintf("Block has %v records, but %v bytes remain after decoding them", r.Records, r.Remaining)
+}
+
+// CodecMismatchError is returned when files which should share a codec use different codecs
+type CodecMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func NewCodecMismatchError(expected, actual string) *CodecMismatchError {
+	return &CodecMismatchError{
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+func (c *CodecMismatchError) Error() string {
+	return fmt.Sprintf("Codec mismatch: expected %q, got %q", c.Expected, c.Actual)
+}
+
+// InputError identifies which of several input files caused an error
+type InputError struct {
+	Input int
+	Err   error
+}
+
+func NewInputError(input int, err error) *InputError {
+	return &InputError{
+		Input: input,
+		Err:   err,
+	}
+}
+
+func (i *InputError) Error() string {
+	return fmt.Sprintf("Error in input %v: %v", i.Input, i.Err)
+}
+
+// InvalidSchemaError is returned when a Writer is created with a schema which can't be parsed
+type InvalidSchemaError struct {
+	Schema string
+	Err    error
+}
+
+func NewInvalidSchemaError(schema string, err error) *InvalidSchemaError {
+	return &InvalidSchemaError{
+		Schema: schema,
+		Err:    err,
+	}
+}
+
+func (i *InvalidSchemaError) Error() string {
+	return fmt.Sprintf("Invalid schema %v: %v", i.Schema, i.Err)
+}
+
+// IncompleteBlockError is returned when appending to a file which ends with a partly written block, such as a file
+// left behind by a writer which crashed, if the file can't be truncated to remove the partial block
+type IncompleteBlockError struct {
+	// The offset of the start of the partial block, where the file should be truncated
+	Offset int64
+	// The error reading the partial block
+	Err error
+}
+
+func NewIncompleteBlockError(offset int64, err error) *IncompleteBlockError {
+	return &IncompleteBlockError{
+		Offset: offset,
+		Err:    err,
+	}
+}
+
+func (i *IncompleteBlockError) Error() string {
+	return fmt.Sprintf("File ends with an incomplete block at offset %v: %v", i.Offset, i.Err)
+}
@@ -0,0 +1,39 @@
+package container
+
+import (
+	"time"
+)
+
+// A WriterObserver is notified of every record and block a Writer writes, so the Writer can be instrumented
+// with a metrics library. When blocks are compressed with WithCompressionWorkers, BlockWritten is called from
+// the goroutine writing blocks rather than the goroutine calling WriteRecord, so implementations must be safe
+// for concurrent use.
+type WriterObserver interface {
+	// Called for each call to WriteRecord, with the size of the serialized record in bytes,
+	// or the error if the record couldn't be written
+	RecordWritten(size int, err error)
+	// Called once each block has been written to the underlying io.Writer, or has failed to be written
+	BlockWritten(stats BlockStats)
+}
+
+// BlockStats describes a block written by a Writer
+type BlockStats struct {
+	Records int64
+	// The size of the block's records before and after compression. UncompressedBytes is 0 for blocks
+	// copied with WriteBlock, since they're already compressed.
+	UncompressedBytes int
+	CompressedBytes   int
+	// The time spent compressing the block and writing it to the underlying io.Writer
+	CompressTime time.Duration
+	WriteTime    time.Duration
+	// The error compressing or writing the block, if there was one
+	Err error
+}
+
+// Call the observer's methods for every record and block written
+func WithObserver(observer WriterObserver) WriterOption {
+	return func(w *Writer) error {
+		w.observer = observer
+		return nil
+	}
+}
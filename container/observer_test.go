@@ -0,0 +1,132 @@
+package container
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// An observer which keeps totals of everything it's notified of
+type countingObserver struct {
+	lock              sync.Mutex
+	records           int64
+	recordBytes       int
+	recordErrors      int
+	blocks            int
+	blockRecords      int64
+	uncompressedBytes int
+	compressedBytes   int
+	blockErrors       int
+}
+
+func (c *countingObserver) RecordWritten(size int, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		c.recordErrors += 1
+		return
+	}
+	c.records += 1
+	c.recordBytes += size
+}
+
+func (c *countingObserver) BlockWritten(stats BlockStats) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if stats.Err != nil {
+		c.blockErrors += 1
+		return
+	}
+	c.blocks += 1
+	c.blockRecords += stats.Records
+	c.uncompressedBytes += stats.UncompressedBytes
+	c.compressedBytes += stats.CompressedBytes
+}
+
+// A record which doesn't match the schema of the files in these tests
+type otherRecord struct{}
+
+func (o *otherRecord) Schema() string {
+	return `{"type": "record", "name": "other", "fields": []}`
+}
+
+func (o *otherRecord) Serialize(w io.Writer) error {
+	return nil
+}
+
+func observeWriter(t *testing.T, opts ...WriterOption) *countingObserver {
+	observer := &countingObserver{}
+	var buf bytes.Buffer
+	opts = append(opts, WithObserver(observer))
+	writer, err := NewWriter(&buf, Deflate, 10, longRecord(0).Schema(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 95; i++ {
+		// Records of different sizes
+		err = writer.WriteRecord(longRecord(i * i * i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.IsType(t, &SchemaMismatchError{}, writer.WriteRecord(&otherRecord{}))
+	assert.Nil(t, writer.Close())
+
+	assert.Equal(t, int64(95), observer.records)
+	assert.Equal(t, 1, observer.recordErrors)
+	assert.Equal(t, 10, observer.blocks)
+	assert.Equal(t, int64(95), observer.blockRecords)
+	assert.Equal(t, observer.recordBytes, observer.uncompressedBytes)
+	assert.Equal(t, 0, observer.blockErrors)
+
+	// The compressed blocks and their framing should make up the rest of the file after the header
+	reader, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compressedBytes := 0
+	for {
+		block, err := reader.NextBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		compressedBytes += len(block.RecordBytes)
+	}
+	assert.Equal(t, compressedBytes, observer.compressedBytes)
+	return observer
+}
+
+func TestWriterObserver(t *testing.T) {
+	observeWriter(t)
+}
+
+func TestWriterObserverCompressionWorkers(t *testing.T) {
+	observeWriter(t, WithCompressionWorkers(3, 2))
+}
+
+func TestWriterObserverBlockError(t *testing.T) {
+	observer := &countingObserver{}
+	writer, err := NewWriter(&limitedWriter{limit: 1024}, Null, 1, longRecord(0).Schema(), WithObserver(observer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		err = writer.WriteRecord(longRecord(i))
+		if err != nil {
+			break
+		}
+	}
+
+	assert.Equal(t, io.ErrShortWrite, err)
+	assert.Equal(t, 1, observer.blockErrors)
+}
@@ -0,0 +1,79 @@
+package container
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Metadata keys with this prefix are reserved for use by the Avro spec
+const reservedMetadataPrefix = "avro."
+
+// A WriterOption configures optional behaviour of a Writer when it's created with NewWriter
+type WriterOption func(*Writer) error
+
+// Use the given sync marker instead of generating a random one. This is useful for deterministic
+// output in tests, or to continue writing blocks to a file which already has a sync marker.
+func WithSyncMarker(syncMarker [16]byte) WriterOption {
+	return func(w *Writer) error {
+		w.syncMarker = syncMarker
+		return nil
+	}
+}
+
+// Add user metadata to the file header, such as the name of the producer or the time the file was created.
+// Keys in the `avro.` namespace are reserved by the Avro spec, and will cause NewWriter to return a ReservedMetadataKeyError.
+// This option can be passed multiple times, later values for a key replace earlier ones.
+func WithMetadata(metadata map[string][]byte) WriterOption {
+	return func(w *Writer) error {
+		if w.metadata == nil {
+			w.metadata = make(map[string][]byte)
+		}
+		for k, v := range metadata {
+			if strings.HasPrefix(k, reservedMetadataPrefix) {
+				return NewReservedMetadataKeyError(k)
+			}
+			w.metadata[k] = v
+		}
+		return nil
+	}
+}
+
+// Flush blocks once the uncompressed size of the records in the block reaches blockSize bytes, even if
+// the block has fewer than recordsPerBlock records. This bounds the memory used by the Writer when records are large.
+// To flush blocks on size alone, pass math.MaxInt64 as recordsPerBlock.
+func WithBlockSize(blockSize int) WriterOption {
+	return func(w *Writer) error {
+		if blockSize <= 0 {
+			return fmt.Errorf("Block size must be positive, got %v", blockSize)
+		}
+		w.blockSize = blockSize
+		return nil
+	}
+}
+
+// Flush blocks once the first record in the block was written more than maxBlockAge ago. The age is checked
+// when records are written, so callers which write infrequently should also call Flush periodically.
+func WithMaxBlockAge(maxBlockAge time.Duration) WriterOption {
+	return func(w *Writer) error {
+		if maxBlockAge <= 0 {
+			return fmt.Errorf("Max block age must be positive, got %v", maxBlockAge)
+		}
+		w.maxBlockAge = maxBlockAge
+		return nil
+	}
+}
+
+// Compress blocks on a pool of workers goroutines instead of the goroutine calling WriteRecord. Blocks are still
+// written to the underlying io.Writer in order. At most maxInFlight full blocks are buffered waiting to be written,
+// after which WriteRecord blocks until the oldest block is written. Flush waits for every block to be written,
+// and returns the first error from compressing or writing any block.
+func WithCompressionWorkers(workers, maxInFlight int) WriterOption {
+	return func(w *Writer) error {
+		if workers <= 0 || maxInFlight <= 0 {
+			return fmt.Errorf("Compression workers and max in-flight blocks must be positive, got %v and %v", workers, maxInFlight)
+		}
+		w.pipeline = newCompressionPipeline(w.writer, w.codec, workers, maxInFlight)
+		return nil
+	}
+}
@@ -0,0 +1,216 @@
+package container
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/actgardner/gogen-avro/container/avro"
+)
+
+// A DatumDecoder reads a single record from r, for example by calling a generated Deserialize method
+type DatumDecoder func(r io.Reader) (interface{}, error)
+
+// A block which has been handed to a worker to decode
+type decodedBlock struct {
+	block   *avro.AvroContainerBlock
+	records []interface{}
+	err     error
+	// Closed once the block has been decoded
+	decoded chan struct{}
+}
+
+// ParallelReader reads blocks from a Reader sequentially, and decompresses and decodes them on a pool of goroutines.
+type ParallelReader struct {
+	reader  *Reader
+	decode  DatumDecoder
+	ordered bool
+
+	jobs      chan *decodedBlock
+	inOrder   chan *decodedBlock
+	results   chan *decodedBlock
+	stop      chan struct{}
+	closeOnce sync.Once
+	// Tracks the goroutines reading and decoding blocks, so Close can wait for them to stop using the Reader
+	running sync.WaitGroup
+
+	current *decodedBlock
+	index   int
+	record  interface{}
+	err     error
+}
+
+// Create a ParallelReader which reads the remaining blocks from reader, and decodes each record with decode on one of workers goroutines.
+// If ordered is true, records are returned in the order they appear in the file. Otherwise the records from each block are returned
+// together in the order they were in the block, but blocks are returned as soon as they've been decoded, which is faster when blocks vary in size.
+// The goroutines run until every block has been read or Close is called.
+func NewParallelReader(reader *Reader, decode DatumDecoder, workers int, ordered bool) *ParallelReader {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	p := &ParallelReader{
+		reader:  reader,
+		decode:  decode,
+		ordered: ordered,
+		jobs:    make(chan *decodedBlock, workers),
+		inOrder: make(chan *decodedBlock, 2*workers),
+		results: make(chan *decodedBlock, 2*workers),
+		stop:    make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	p.running.Add(workers + 1)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.running.Done()
+			defer wg.Done()
+			p.decodeBlocks()
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(p.results)
+	}()
+
+	go func() {
+		defer p.running.Done()
+		p.readBlocks()
+	}()
+	return p
+}
+
+// Advance to the next record. Returns false when there are no records left or an error occurs, use Err to distinguish the two cases.
+func (p *ParallelReader) Next() bool {
+	for p.err == nil {
+		if p.current != nil && p.index < len(p.current.records) {
+			p.record = p.current.records[p.index]
+			p.index += 1
+			return true
+		}
+
+		block, ok := p.nextBlock()
+		if !ok {
+			break
+		}
+
+		if block.err != nil {
+			p.err = block.err
+			break
+		}
+
+		p.current = block
+		p.index = 0
+	}
+
+	p.record = nil
+	p.Close()
+	return false
+}
+
+// The record read by the most recent call to Next
+func (p *ParallelReader) Record() interface{} {
+	return p.record
+}
+
+// The first error encountered while reading the file, or nil if the file was read successfully
+func (p *ParallelReader) Err() error {
+	return p.err
+}
+
+// Stop the goroutines reading and decoding blocks. Close only needs to be called if not all the records are read.
+// Close waits for a block which is being read to be finished, so the underlying io.Reader can be closed once it returns.
+func (p *ParallelReader) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+	})
+	p.running.Wait()
+}
+
+// Get the next decoded block, in file order or in the order blocks finish decoding
+func (p *ParallelReader) nextBlock() (*decodedBlock, bool) {
+	if p.ordered {
+		block, ok := <-p.inOrder
+		if !ok {
+			return nil, false
+		}
+		<-block.decoded
+		return block, true
+	}
+	block, ok := <-p.results
+	return block, ok
+}
+
+// Read blocks from the file and hand them to the workers, until the end of the file or an error
+func (p *ParallelReader) readBlocks() {
+	defer close(p.jobs)
+	defer close(p.inOrder)
+
+	for {
+		block, err := p.reader.NextBlock()
+		if err == io.EOF {
+			return
+		}
+
+		job := &decodedBlock{
+			block:   block,
+			err:     err,
+			decoded: make(chan struct{}),
+		}
+
+		if p.ordered {
+			select {
+			case p.inOrder <- job:
+			case <-p.stop:
+				return
+			}
+		}
+
+		select {
+		case p.jobs <- job:
+		case <-p.stop:
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *ParallelReader) decodeBlocks() {
+	for job := range p.jobs {
+		if job.err == nil {
+			job.records, job.err = p.decodeBlock(job.block)
+		}
+		close(job.decoded)
+
+		if !p.ordered {
+			select {
+			case p.results <- job:
+			case <-p.stop:
+				return
+			}
+		}
+	}
+}
+
+func (p *ParallelReader) decodeBlock(block *avro.AvroContainerBlock) ([]interface{}, error) {
+	recordBytes, err := p.reader.DecompressBlock(block)
+	if err != nil {
+		return nil, err
+	}
+
+	blockReader := bytes.NewReader(recordBytes)
+	records := make([]interface{}, 0, block.NumRecords)
+	for i := int64(0); i < block.NumRecords; i++ {
+		record, err := p.decode(blockReader)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
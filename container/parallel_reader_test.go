@@ -0,0 +1,128 @@
+package container
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelReaderOrdered(t *testing.T) {
+	reader, err := NewReader(bytes.NewReader(writeRangeContainer(0, 1000, Deflate, 7, t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parallelReader := NewParallelReader(reader, decodeLongRecord, 4, true)
+	var i int
+	for parallelReader.Next() {
+		assert.Equal(t, longRecord(i), parallelReader.Record())
+		i = i + 1
+	}
+	assert.Nil(t, parallelReader.Err())
+	assert.Equal(t, 1000, i)
+}
+
+func TestParallelReaderUnordered(t *testing.T) {
+	reader, err := NewReader(bytes.NewReader(writeRangeContainer(0, 1000, Deflate, 7, t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parallelReader := NewParallelReader(reader, decodeLongRecord, 4, false)
+	seen := make(map[longRecord]bool)
+	for parallelReader.Next() {
+		seen[parallelReader.Record().(longRecord)] = true
+	}
+	assert.Nil(t, parallelReader.Err())
+	assert.Equal(t, 1000, len(seen))
+}
+
+func TestParallelReaderInvalidSyncMarker(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 1000, Deflate, 7, t)
+
+	// Corrupt the sync marker at the end of the last block
+	fileBytes[len(fileBytes)-1] ^= 0xff
+
+	reader, err := NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parallelReader := NewParallelReader(reader, decodeLongRecord, 4, true)
+	var i int
+	for parallelReader.Next() {
+		i = i + 1
+	}
+	assert.IsType(t, &SyncMarkerError{}, parallelReader.Err())
+	assert.Equal(t, 994, i)
+}
+
+func TestParallelReaderClose(t *testing.T) {
+	reader, err := NewReader(bytes.NewReader(writeRangeContainer(0, 1000, Deflate, 7, t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parallelReader := NewParallelReader(reader, decodeLongRecord, 2, false)
+	assert.True(t, parallelReader.Next())
+	parallelReader.Close()
+}
+
+// A reader which blocks once its data has been read, until it's released
+type blockingReader struct {
+	data        io.Reader
+	enteredOnce sync.Once
+	entered     chan struct{}
+	release     chan struct{}
+	returned    int32
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	n, err := b.data.Read(p)
+	if err != io.EOF {
+		return n, err
+	}
+
+	b.enteredOnce.Do(func() {
+		close(b.entered)
+	})
+	<-b.release
+	atomic.StoreInt32(&b.returned, 1)
+	return 0, io.EOF
+}
+
+func TestParallelReaderCloseWaitsForRead(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 14, Deflate, 7, t)
+
+	// Hold back the last byte, so the reader goroutine blocks reading the second block
+	blocking := &blockingReader{
+		data:    bytes.NewReader(fileBytes[:len(fileBytes)-1]),
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	reader, err := NewReader(blocking)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parallelReader := NewParallelReader(reader, decodeLongRecord, 1, false)
+	assert.True(t, parallelReader.Next())
+	<-blocking.entered
+
+	closed := make(chan struct{})
+	go func() {
+		parallelReader.Close()
+		close(closed)
+	}()
+
+	// Only finish the read once Close has been called, so Close has to wait for it
+	<-parallelReader.stop
+	close(blocking.release)
+	<-closed
+	assert.Equal(t, int32(1), atomic.LoadInt32(&blocking.returned))
+}
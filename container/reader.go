@@ -0,0 +1,279 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/actgardner/gogen-avro/container/avro"
+)
+
+// The length of the sync marker which follows the header and every block
+const syncSize = 16
+
+// Corrupt files can have an arbitrarily large block size, so buffers for larger blocks grow as the bytes are read rather than being allocated up front
+const maxPreallocatedBlockSize = 16 * 1024 * 1024
+
+// Reader wraps an io.Reader and parses the file and block-level framing of an OCF file
+type Reader struct {
+	reader       *bufio.Reader
+	source       *countingReader
+	header       *avro.AvroContainerHeader
+	codec        Codec
+	blockReader  *bytes.Reader
+	blockRecords int64
+	// Blocks are only read if the sync marker before them starts before end
+	end int64
+}
+
+// countingReader tracks the offset in the file of the bytes read from the underlying io.Reader
+type countingReader struct {
+	reader io.Reader
+	offset int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.offset += int64(n)
+	return n, err
+}
+
+// Create a new Reader wrapping the provided io.Reader. The container file header is read and
+// validated immediately, so the schema and codec are available as soon as the Reader is returned.
+// The io.Reader doesn't need to support seeking, so files can be streamed from stdin or a network connection.
+func NewReader(reader io.Reader) (*Reader, error) {
+	source := &countingReader{reader: reader}
+	bufReader := bufio.NewReader(source)
+	header, err := avro.DeserializeAvroContainerHeader(bufReader)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Magic != magicBytes {
+		return nil, ErrInvalidMagic
+	}
+
+	codec := Null
+	if codecName, ok := header.Meta["avro.codec"]; ok && len(codecName) > 0 {
+		codec, err = LookupCodec(string(codecName))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Reader{
+		reader:      bufReader,
+		source:      source,
+		header:      header,
+		codec:       codec,
+		blockReader: bytes.NewReader(nil),
+		end:         math.MaxInt64,
+	}, nil
+}
+
+// Create a Reader for the blocks of a container file which belong to the byte range [start, end), so
+// a large file can be divided into splits which are read independently. The header is read from the
+// start of the file, then the reader seeks to start and skips forward to the next sync marker.
+// A block belongs to the range containing the first byte of the sync marker which precedes it, so
+// readers for adjacent ranges never read the same block twice or miss a block between them.
+// Readers in different goroutines need their own io.ReadSeeker, for example an io.SectionReader for each split.
+func NewSplitReader(file io.ReadSeeker, start, end int64) (*Reader, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	r, err := NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	r.end = end
+
+	// The first block in the file follows the header's sync marker
+	if start <= r.Offset()-syncSize {
+		return r, nil
+	}
+
+	if err = r.seek(file, start); err != nil {
+		return nil, err
+	}
+
+	err = r.skipToSyncMarker()
+	if err == io.EOF {
+		// There are no blocks in the range, so the next block read will return io.EOF
+		return r, nil
+	}
+	return r, err
+}
+
+// The schema of the records in the file, from the `avro.schema` header metadata
+func (r *Reader) Schema() string {
+	return string(r.header.Meta["avro.schema"])
+}
+
+// The codec used to compress blocks in the file, from the `avro.codec` header metadata
+func (r *Reader) Codec() Codec {
+	return r.codec
+}
+
+// All of the metadata stored in the file header, including the `avro.schema` and `avro.codec` keys
+func (r *Reader) Metadata() map[string][]byte {
+	return r.header.Meta
+}
+
+// The metadata stored in the file header outside the reserved `avro.` namespace, which can be passed to WithMetadata
+func (r *Reader) UserMetadata() map[string][]byte {
+	metadata := make(map[string][]byte)
+	for k, v := range r.header.Meta {
+		if !strings.HasPrefix(k, reservedMetadataPrefix) {
+			metadata[k] = v
+		}
+	}
+	return metadata
+}
+
+// The sync marker which follows every block in the file
+func (r *Reader) SyncMarker() avro.Sync {
+	return r.header.Sync
+}
+
+// The offset in the file of the next block to be read. Records returned by Next
+// come from a block which has already been read.
+func (r *Reader) Offset() int64 {
+	return r.source.offset - int64(r.reader.Buffered())
+}
+
+// Verify that the records in the file can be read with the given schema. Schemas are compared by
+// their Parsing Canonical Form, like records passed to Writer.WriteRecord, so schemas which only differ
+// in formatting, docs, aliases or defaults are considered equivalent.
+// Returns a SchemaMismatchError if the schemas differ.
+func (r *Reader) CheckSchema(schema string) error {
+	if !schemasEqual(r.Schema(), schema) {
+		return NewSchemaMismatchError(schema, r.Schema())
+	}
+	return nil
+}
+
+// Advance to the next record in the file, reading and decompressing a new block if necessary.
+// The returned io.Reader is positioned at the start of the record's datum, and the caller must
+// read exactly one datum from it (for example with a generated Deserialize method) before calling Next again.
+// Returns io.EOF once every record in the file has been read.
+func (r *Reader) Next() (io.Reader, error) {
+	for r.blockRecords == 0 {
+		block, err := r.readBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		recordBytes, err := r.DecompressBlock(block)
+		if err != nil {
+			return nil, err
+		}
+
+		r.blockReader.Reset(recordBytes)
+		r.blockRecords = block.NumRecords
+	}
+
+	r.blockRecords -= 1
+	return r.blockReader, nil
+}
+
+// Read the next block from the file without decompressing it, and verify its sync marker.
+// Any records remaining in the block being read by Next are skipped.
+// Returns io.EOF if the file ends cleanly before the block starts.
+func (r *Reader) NextBlock() (*avro.AvroContainerBlock, error) {
+	r.blockRecords = 0
+	return r.readBlock()
+}
+
+// Decompress the records in a block read by NextBlock with the file's codec
+func (r *Reader) DecompressBlock(block *avro.AvroContainerBlock) ([]byte, error) {
+	return r.codec.Decompress(block.RecordBytes)
+}
+
+// Read the framing of the next block and verify its sync marker. The returned block still holds
+// compressed record bytes. Returns io.EOF if the file ends cleanly before the block starts.
+func (r *Reader) readBlock() (*avro.AvroContainerBlock, error) {
+	if r.Offset()-syncSize >= r.end {
+		return nil, io.EOF
+	}
+
+	numRecords, err := binary.ReadVarint(r.reader)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := binary.ReadVarint(r.reader)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	if numRecords < 0 || size < 0 {
+		return nil, fmt.Errorf("Invalid block header: %v records in %v bytes", numRecords, size)
+	}
+
+	block := &avro.AvroContainerBlock{
+		NumRecords: numRecords,
+	}
+
+	if size <= maxPreallocatedBlockSize {
+		block.RecordBytes = make([]byte, size)
+		_, err = io.ReadFull(r.reader, block.RecordBytes)
+	} else {
+		var buf bytes.Buffer
+		_, err = io.CopyN(&buf, r.reader, size)
+		block.RecordBytes = buf.Bytes()
+	}
+
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	if _, err = io.ReadFull(r.reader, block.Sync[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	if block.Sync != r.header.Sync {
+		return nil, NewSyncMarkerError(r.header.Sync, block.Sync)
+	}
+	return block, nil
+}
+
+// Move to offset in the file, which must be the io.ReadSeeker the Reader was created with
+func (r *Reader) seek(file io.ReadSeeker, offset int64) error {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	r.source = &countingReader{reader: file, offset: offset}
+	r.reader.Reset(r.source)
+	r.blockRecords = 0
+	return nil
+}
+
+// Read forward until just after the next sync marker in the file
+func (r *Reader) skipToSyncMarker() error {
+	var window avro.Sync
+	for read := 0; ; read++ {
+		b, err := r.reader.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		copy(window[:], window[1:])
+		window[syncSize-1] = b
+		if read >= syncSize-1 && window == r.header.Sync {
+			return nil
+		}
+	}
+}
+
+// A file which ends partway through a block is truncated, rather than cleanly finished
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
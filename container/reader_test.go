@@ -0,0 +1,107 @@
+package container
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Read records from a container file until an error, returning the error
+func readUntilError(fileBytes []byte, t *testing.T) error {
+	reader, err := NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		datumReader, err := reader.Next()
+		if err != nil {
+			return err
+		}
+		if _, err = decodeLongRecord(datumReader); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReaderInvalidSyncMarker(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 10, Null, 2, t)
+	// Corrupt the final byte of the last block's sync marker
+	fileBytes[len(fileBytes)-1] ^= 0xff
+	assert.IsType(t, &SyncMarkerError{}, readUntilError(fileBytes, t))
+}
+
+func TestReaderSnappyChecksum(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 10, Snappy, 2, t)
+	// Flip a bit in the CRC suffix of the last block, just before the sync marker
+	fileBytes[len(fileBytes)-17] ^= 0x01
+	assert.IsType(t, &ChecksumError{}, readUntilError(fileBytes, t))
+}
+
+func TestReaderTruncatedFile(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 10, Null, 2, t)
+	assert.Equal(t, io.ErrUnexpectedEOF, readUntilError(fileBytes[:len(fileBytes)-5], t))
+}
+
+func readSplits(fileBytes []byte, splits int, t *testing.T) []longRecord {
+	splitSize := int64(len(fileBytes)/splits + 1)
+	var records []longRecord
+	for start := int64(0); start < int64(len(fileBytes)); start += splitSize {
+		file := io.NewSectionReader(bytes.NewReader(fileBytes), 0, int64(len(fileBytes)))
+		reader, err := NewSplitReader(file, start, start+splitSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for {
+			datumReader, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := decodeLongRecord(datumReader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, record.(longRecord))
+		}
+	}
+	return records
+}
+
+func TestSplitReader(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 1000, Deflate, 7, t)
+
+	for _, splits := range []int{1, 2, 3, 10, 50, 1000} {
+		records := readSplits(fileBytes, splits, t)
+		assert.Equal(t, 1000, len(records))
+		for i, record := range records {
+			assert.Equal(t, longRecord(i), record)
+		}
+	}
+}
+
+func TestSplitReaderEmptyRange(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 1000, Deflate, 7, t)
+
+	// A range inside the header, before the first block
+	reader, err := NewSplitReader(bytes.NewReader(fileBytes), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = reader.NextBlock()
+	assert.Equal(t, io.EOF, err)
+
+	// A range after the last sync marker
+	reader, err = NewSplitReader(bytes.NewReader(fileBytes), int64(len(fileBytes)-8), int64(len(fileBytes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = reader.NextBlock()
+	assert.Equal(t, io.EOF, err)
+}
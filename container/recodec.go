@@ -0,0 +1,94 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Copy the container file read from r to w, decompressing each block and compressing it again with codec, without
+// decoding and encoding the records. The output has the schema and user metadata of the input, and a new sync marker
+// unless one is passed with WithSyncMarker.
+// If recordsPerBlock is 0 input blocks are kept whole, and decode isn't used. Each input block is written as an output block,
+// or if WithBlockSize is used, consecutive input blocks are combined until the uncompressed size of the output block reaches
+// the target, so files with many small blocks can be rechunked without decoding the records.
+// Otherwise the records are regrouped into blocks of recordsPerBlock records, or smaller blocks if WithBlockSize or
+// WithMaxBlockAge are used. Regrouping needs to find where each record ends, so decode must be able to read records with
+// the file's schema, and a RecordCountError is returned if a block doesn't hold the number of records in its header.
+func Recodec(w io.Writer, r io.Reader, codec Codec, recordsPerBlock int64, decode DatumDecoder, opts ...WriterOption) error {
+	if recordsPerBlock < 0 {
+		return fmt.Errorf("Records per block must not be negative, got %v", recordsPerBlock)
+	}
+
+	if recordsPerBlock > 0 && decode == nil {
+		return fmt.Errorf("A DatumDecoder is required to change the number of records per block")
+	}
+
+	reader, err := NewReader(r)
+	if err != nil {
+		return err
+	}
+
+	opts = append([]WriterOption{WithMetadata(reader.UserMetadata())}, opts...)
+	writer, err := NewWriter(w, codec, recordsPerBlock, reader.Schema(), opts...)
+	if err != nil {
+		return err
+	}
+
+	// Whole input blocks are written as soon as they've been added, unless they're being combined up to a target size
+	if recordsPerBlock == 0 {
+		writer.recordsPerBlock = 1
+		if writer.blockSize > 0 {
+			writer.recordsPerBlock = math.MaxInt64
+		}
+	}
+
+	for {
+		block, err := reader.NextBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		recordBytes, err := reader.DecompressBlock(block)
+		if err != nil {
+			return err
+		}
+
+		if recordsPerBlock == 0 {
+			if err = writer.writeSerialized(recordBytes, block.NumRecords); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err = regroupRecords(writer, block.NumRecords, recordBytes, decode); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// Split a block's records using decode to find where each one ends, and add them to the writer one at a time
+func regroupRecords(writer *Writer, count int64, recordBytes []byte, decode DatumDecoder) error {
+	blockReader := bytes.NewReader(recordBytes)
+	for i := int64(0); i < count; i++ {
+		start := len(recordBytes) - blockReader.Len()
+		if _, err := decode(blockReader); err != nil {
+			return NewRecordCountError(count, i, blockReader.Len(), err)
+		}
+
+		end := len(recordBytes) - blockReader.Len()
+		if err := writer.writeSerialized(recordBytes[start:end], 1); err != nil {
+			return err
+		}
+	}
+
+	if blockReader.Len() > 0 {
+		return NewRecordCountError(count, count, blockReader.Len(), nil)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package container
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func blockRecordCounts(fileBytes []byte, t *testing.T) []int64 {
+	reader, err := NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make([]int64, 0)
+	for {
+		block, err := reader.NextBlock()
+		if err == io.EOF {
+			return counts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts = append(counts, block.NumRecords)
+	}
+}
+
+func checkRecodec(fileBytes []byte, codec Codec, count int, t *testing.T) {
+	checkRangeContainer(fileBytes, 0, count, t)
+
+	reader, err := NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, codec.Name(), reader.Codec().Name())
+	assert.Equal(t, map[string][]byte{"producer": []byte("test")}, reader.UserMetadata())
+}
+
+func TestRecodec(t *testing.T) {
+	input := writeRangeContainer(0, 10, Null, 3, t, WithMetadata(map[string][]byte{"producer": []byte("test")}))
+
+	var buf bytes.Buffer
+	err := Recodec(&buf, bytes.NewReader(input), Deflate, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRecodec(buf.Bytes(), Deflate, 10, t)
+	assert.Equal(t, []int64{3, 3, 3, 1}, blockRecordCounts(buf.Bytes(), t))
+}
+
+func TestRecodecRegroup(t *testing.T) {
+	input := writeRangeContainer(0, 10, Snappy, 3, t, WithMetadata(map[string][]byte{"producer": []byte("test")}))
+
+	var buf bytes.Buffer
+	err := Recodec(&buf, bytes.NewReader(input), Zstandard, 4, decodeLongRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRecodec(buf.Bytes(), Zstandard, 10, t)
+	assert.Equal(t, []int64{4, 4, 2}, blockRecordCounts(buf.Bytes(), t))
+
+	err = Recodec(&buf, bytes.NewReader(input), Zstandard, 4, nil)
+	assert.NotNil(t, err)
+}
+
+func TestRecodecTargetBytes(t *testing.T) {
+	input := writeRangeContainer(0, 100, Null, 3, t, WithMetadata(map[string][]byte{"producer": []byte("test")}))
+
+	var buf bytes.Buffer
+	err := Recodec(&buf, bytes.NewReader(input), Snappy, 0, nil, WithBlockSize(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRecodec(buf.Bytes(), Snappy, 100, t)
+
+	// Input blocks of 3 records are combined without being split, until the output block holds at least 40 bytes
+	counts := blockRecordCounts(buf.Bytes(), t)
+	assert.True(t, len(counts) > 1)
+	assert.True(t, len(counts) < len(blockRecordCounts(input, t)))
+	for _, count := range counts[:len(counts)-1] {
+		assert.Equal(t, int64(0), count%3)
+	}
+
+	reader, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(counts)-1; i++ {
+		block, err := reader.NextBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recordBytes, err := reader.DecompressBlock(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, len(recordBytes) >= 40)
+	}
+}
+
+func TestRecodecRecordCount(t *testing.T) {
+	reader, err := NewReader(bytes.NewReader(writeRangeContainer(0, 3, Null, 3, t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := reader.NextBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Copy the block with a header which claims one more record than it holds
+	block.NumRecords += 1
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, Null, 10, reader.Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.WriteBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Recodec(ioutil.Discard, bytes.NewReader(buf.Bytes()), Deflate, 5, decodeLongRecord)
+	assert.IsType(t, &RecordCountError{}, err)
+}
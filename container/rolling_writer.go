@@ -0,0 +1,309 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// A FileFactory creates the next file for a RollingWriter. The name is only used to identify the file in the RolledFile
+// passed to the OnFileComplete callback, so it can be a path, an object store key or anything else.
+type FileFactory func() (name string, file io.WriteCloser, err error)
+
+// RolledFile describes a file which a RollingWriter has finished writing and closed
+type RolledFile struct {
+	Name    string
+	Records int64
+	// The size of the file, including the header
+	Bytes int64
+}
+
+// A RollingOption configures when a RollingWriter moves on to a new file, and how each file is written
+type RollingOption func(*RollingWriter) error
+
+// Start a new file once the current file is at least maxBytes long. Only blocks which have been written count
+// towards the size, so files can be larger than maxBytes by up to one block.
+func RollAfterBytes(maxBytes int64) RollingOption {
+	return func(r *RollingWriter) error {
+		if maxBytes <= 0 {
+			return fmt.Errorf("Max file size must be positive, got %v", maxBytes)
+		}
+		r.maxBytes = maxBytes
+		return nil
+	}
+}
+
+// Start a new file once maxRecords records have been written to the current file
+func RollAfterRecords(maxRecords int64) RollingOption {
+	return func(r *RollingWriter) error {
+		if maxRecords <= 0 {
+			return fmt.Errorf("Max records per file must be positive, got %v", maxRecords)
+		}
+		r.maxRecords = maxRecords
+		return nil
+	}
+}
+
+// Start a new file once the current file was opened more than maxAge ago. The age is checked before and after each
+// record is written, so callers which write infrequently should also call Roll periodically to finish idle files.
+func RollAfterDuration(maxAge time.Duration) RollingOption {
+	return func(r *RollingWriter) error {
+		if maxAge <= 0 {
+			return fmt.Errorf("Max file age must be positive, got %v", maxAge)
+		}
+		r.maxAge = maxAge
+		return nil
+	}
+}
+
+// Call onComplete after each file has been written and closed successfully
+func OnFileComplete(onComplete func(RolledFile)) RollingOption {
+	return func(r *RollingWriter) error {
+		r.onComplete = onComplete
+		return nil
+	}
+}
+
+// Pass the given options to the Writer for each file
+func WithFileWriterOptions(opts ...WriterOption) RollingOption {
+	return func(r *RollingWriter) error {
+		r.writerOpts = append(r.writerOpts, opts...)
+		return nil
+	}
+}
+
+// RollingWriter writes records to a series of container files, starting a new file once the current one reaches
+// a size, record count or age limit. Files are created with a FileFactory when the first record for them is written.
+// The first record's schema is checked and the record is serialized before the file is created, so a record which
+// can't be written doesn't create a file. If writing the first record to a new file fails, the file is closed
+// without being passed to the OnFileComplete callback.
+type RollingWriter struct {
+	factory         FileFactory
+	codec           Codec
+	recordsPerBlock int64
+	schema          string
+	parsedSchema    *writerSchema
+	writerOpts      []WriterOption
+	maxBytes        int64
+	maxRecords      int64
+	maxAge          time.Duration
+	onComplete      func(RolledFile)
+
+	writer   *Writer
+	file     io.WriteCloser
+	fileName string
+	counter  *countingWriter
+	records  int64
+	opened   time.Time
+	closed   bool
+}
+
+// countingWriter tracks the number of bytes written to the underlying io.Writer. Blocks may be written by
+// the Writer's compression goroutines, so the count is updated and read atomically.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	atomic.AddInt64(&c.count, int64(n))
+	return n, err
+}
+
+func (c *countingWriter) written() int64 {
+	return atomic.LoadInt64(&c.count)
+}
+
+// serializedRecord is a record which has already been serialized, so writing it can't fail to serialize
+type serializedRecord struct {
+	schema string
+	bytes  []byte
+}
+
+func (s *serializedRecord) Schema() string {
+	return s.schema
+}
+
+func (s *serializedRecord) Serialize(w io.Writer) error {
+	_, err := w.Write(s.bytes)
+	return err
+}
+
+// Create a RollingWriter which writes records with the given schema to files created by factory. Each file is written
+// by a Writer with the given codec and number of records per block. An InvalidSchemaError is returned if the schema can't be parsed.
+func NewRollingWriter(factory FileFactory, codec Codec, recordsPerBlock int64, schema string, opts ...RollingOption) (*RollingWriter, error) {
+	parsedSchema, err := newWriterSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	rollingWriter := &RollingWriter{
+		factory:         factory,
+		codec:           codec,
+		recordsPerBlock: recordsPerBlock,
+		schema:          schema,
+		parsedSchema:    parsedSchema,
+	}
+
+	for _, opt := range opts {
+		err := opt(rollingWriter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rollingWriter, nil
+}
+
+// Write a record to the current file, creating a new file first if necessary. Once the current file
+// reaches one of the limits it is finished and closed, and the next record starts a new file.
+func (r *RollingWriter) WriteRecord(record AvroRecord) error {
+	if r.closed {
+		return ErrWriterClosed
+	}
+
+	// Don't add a new record to a file which is already too old
+	if r.writer != nil && r.expired() {
+		err := r.Roll()
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.writer == nil {
+		// Check the record can be written before creating a file for it
+		_, err := r.parsedSchema.branch(record.Schema())
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		err = record.Serialize(&buf)
+		if err != nil {
+			return err
+		}
+		record = &serializedRecord{schema: record.Schema(), bytes: buf.Bytes()}
+
+		err = r.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := r.writer.WriteRecord(record)
+	if err != nil {
+		if r.records == 0 {
+			r.discard()
+		}
+		return err
+	}
+	r.records += 1
+
+	if r.full() {
+		return r.Roll()
+	}
+	return nil
+}
+
+// Write the current block to the current file, without finishing the file
+func (r *RollingWriter) Flush() error {
+	if r.closed {
+		return ErrWriterClosed
+	}
+
+	if r.writer == nil {
+		return nil
+	}
+	return r.writer.Flush()
+}
+
+// Finish and close the current file, if any records have been written to it. The next record starts a new file.
+// The OnFileComplete callback is called once the file has been closed successfully.
+func (r *RollingWriter) Roll() error {
+	if r.closed {
+		return ErrWriterClosed
+	}
+
+	if r.writer == nil {
+		return nil
+	}
+
+	err := r.writer.Close()
+	closeErr := r.file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	completed := RolledFile{
+		Name:    r.fileName,
+		Records: r.records,
+		Bytes:   r.counter.written(),
+	}
+
+	r.writer = nil
+	r.file = nil
+	r.counter = nil
+	r.records = 0
+
+	if err != nil {
+		return err
+	}
+
+	if r.onComplete != nil {
+		r.onComplete(completed)
+	}
+	return nil
+}
+
+// Finish and close the current file. After Close, WriteRecord, Flush, Roll and Close return ErrWriterClosed.
+func (r *RollingWriter) Close() error {
+	err := r.Roll()
+	r.closed = true
+	return err
+}
+
+// Close a file which no records have been written to, without calling the OnFileComplete callback
+func (r *RollingWriter) discard() {
+	r.writer.Close()
+	r.file.Close()
+	r.writer = nil
+	r.file = nil
+	r.counter = nil
+}
+
+func (r *RollingWriter) open() error {
+	name, file, err := r.factory()
+	if err != nil {
+		return err
+	}
+
+	counter := &countingWriter{writer: file}
+	writer, err := NewWriter(counter, r.codec, r.recordsPerBlock, r.schema, r.writerOpts...)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	r.writer = writer
+	r.file = file
+	r.fileName = name
+	r.counter = counter
+	r.opened = time.Now()
+	return nil
+}
+
+func (r *RollingWriter) full() bool {
+	if r.maxRecords > 0 && r.records >= r.maxRecords {
+		return true
+	}
+	if r.maxBytes > 0 && r.counter.written() >= r.maxBytes {
+		return true
+	}
+	return r.expired()
+}
+
+func (r *RollingWriter) expired() bool {
+	return r.maxAge > 0 && time.Since(r.opened) >= r.maxAge
+}
@@ -0,0 +1,48 @@
+package container
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A FileFactory which counts the files it creates and discards their contents
+type discardFiles struct {
+	created int
+}
+
+type discardFile struct {
+	io.Writer
+}
+
+func (discardFile) Close() error {
+	return nil
+}
+
+func (d *discardFiles) create() (string, io.WriteCloser, error) {
+	d.created += 1
+	return "discard.avro", discardFile{ioutil.Discard}, nil
+}
+
+func TestRollingWriterSchemaMismatch(t *testing.T) {
+	files := &discardFiles{}
+	var completed []RolledFile
+	rollingWriter, err := NewRollingWriter(files.create, Null, 5, `"string"`,
+		OnFileComplete(func(f RolledFile) {
+			completed = append(completed, f)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rollingWriter.WriteRecord(longRecord(0))
+	assert.IsType(t, &SchemaMismatchError{}, err)
+	assert.Nil(t, rollingWriter.Close())
+	assert.Equal(t, 0, files.created)
+	assert.Equal(t, 0, len(completed))
+
+	_, err = NewRollingWriter(files.create, Null, 5, `not json`)
+	assert.IsType(t, &InvalidSchemaError{}, err)
+}
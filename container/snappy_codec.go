@@ -0,0 +1,42 @@
+package container
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/golang/snappy"
+)
+
+// Snappy blocks are followed by the CRC32 checksum of the uncompressed data, as required by the Avro spec
+const snappyChecksumSize = 4
+
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string {
+	return "snappy"
+}
+
+func (snappyCodec) Compress(block []byte) ([]byte, error) {
+	encoded := snappy.Encode(nil, block)
+	checksum := make([]byte, snappyChecksumSize)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(block))
+	return append(encoded, checksum...), nil
+}
+
+func (snappyCodec) Decompress(block []byte) ([]byte, error) {
+	if len(block) < snappyChecksumSize {
+		return nil, fmt.Errorf("Snappy block is too short to contain a checksum")
+	}
+
+	decoded, err := snappy.Decode(nil, block[:len(block)-snappyChecksumSize])
+	if err != nil {
+		return nil, err
+	}
+
+	expected := binary.BigEndian.Uint32(block[len(block)-snappyChecksumSize:])
+	if actual := crc32.ChecksumIEEE(decoded); actual != expected {
+		return nil, NewChecksumError(expected, actual)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,122 @@
+package container
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/actgardner/gogen-avro/container/avro"
+)
+
+// DamagedRegion is a range of bytes in a container file which couldn't be read as valid blocks
+type DamagedRegion struct {
+	// The offset of the first block which couldn't be read
+	Offset int64
+	// The number of bytes skipped to reach the next sync marker, or the end of the file
+	Length int64
+	// The reason the first block couldn't be read
+	Err error
+}
+
+// VerifyReport describes the blocks found in a container file by Verify or Salvage
+type VerifyReport struct {
+	// The number of valid blocks, and the records in them
+	Blocks  int64
+	Records int64
+	Damaged []DamagedRegion
+}
+
+// Check every block in a container file: the sync marker after each block, and that the block decompresses
+// (which verifies the checksum for codecs which have one). If decode is not nil, it is called for each record
+// to check the block holds exactly the number of records in its header.
+// After a damaged block, the file is scanned forward to the next sync marker and checking continues from there.
+// An error is returned if the header can't be read; damaged blocks are listed in the report.
+func Verify(file io.ReadSeeker, decode DatumDecoder) (*VerifyReport, error) {
+	reader, err := NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return scanBlocks(file, reader, decode, nil)
+}
+
+// Copy the readable blocks of a damaged container file into a new file written to w, skipping the blocks Verify
+// reports as damaged. The new file has the same header, including the schema, codec and sync marker.
+func Salvage(file io.ReadSeeker, w io.Writer, decode DatumDecoder) (*VerifyReport, error) {
+	reader, err := NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = reader.header.Serialize(w); err != nil {
+		return nil, err
+	}
+
+	return scanBlocks(file, reader, decode, func(block *avro.AvroContainerBlock) error {
+		return block.Serialize(w)
+	})
+}
+
+func scanBlocks(file io.ReadSeeker, reader *Reader, decode DatumDecoder, validBlock func(*avro.AvroContainerBlock) error) (*VerifyReport, error) {
+	report := &VerifyReport{}
+	for {
+		offset := reader.Offset()
+		block, err := reader.NextBlock()
+		if err == io.EOF {
+			return report, nil
+		}
+
+		if err == nil {
+			err = reader.checkBlock(block, decode)
+		}
+
+		if err == nil {
+			report.Blocks += 1
+			report.Records += block.NumRecords
+			if validBlock != nil {
+				if err = validBlock(block); err != nil {
+					return report, err
+				}
+			}
+			continue
+		}
+
+		// The block header may have been corrupted, so look for the next sync marker from the start of the block
+		if seekErr := reader.seek(file, offset); seekErr != nil {
+			return report, seekErr
+		}
+
+		skipErr := reader.skipToSyncMarker()
+		if skipErr != nil && skipErr != io.EOF {
+			return report, skipErr
+		}
+
+		report.Damaged = append(report.Damaged, DamagedRegion{
+			Offset: offset,
+			Length: reader.Offset() - offset,
+			Err:    err,
+		})
+	}
+}
+
+// Decompress a block and, if decode is not nil, check it holds the number of records in the block header
+func (r *Reader) checkBlock(block *avro.AvroContainerBlock, decode DatumDecoder) error {
+	recordBytes, err := r.DecompressBlock(block)
+	if err != nil {
+		return err
+	}
+
+	if decode == nil {
+		return nil
+	}
+
+	blockReader := bytes.NewReader(recordBytes)
+	for i := int64(0); i < block.NumRecords; i++ {
+		if _, err = decode(blockReader); err != nil {
+			return NewRecordCountError(block.NumRecords, i, blockReader.Len(), err)
+		}
+	}
+
+	if blockReader.Len() > 0 {
+		return NewRecordCountError(block.NumRecords, block.NumRecords, blockReader.Len(), nil)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package container
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The offset of each block in a container file
+func blockOffsets(fileBytes []byte, t *testing.T) []int64 {
+	reader, err := NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offsets := make([]int64, 0)
+	for {
+		offset := reader.Offset()
+		_, err := reader.NextBlock()
+		if err == io.EOF {
+			return offsets
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets = append(offsets, offset)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 1000, Deflate, 7, t)
+
+	report, err := Verify(bytes.NewReader(fileBytes), decodeLongRecord)
+	assert.Nil(t, err)
+	assert.Equal(t, &VerifyReport{Blocks: 143, Records: 1000}, report)
+}
+
+func TestVerifyInvalidMagic(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 10, Deflate, 7, t)
+	fileBytes[0] = 'X'
+
+	_, err := Verify(bytes.NewReader(fileBytes), decodeLongRecord)
+	assert.Equal(t, ErrInvalidMagic, err)
+}
+
+func TestVerifyRecordCount(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 1000, Deflate, 7, t)
+	offsets := blockOffsets(fileBytes, t)
+
+	// Change the record count of the fifth block from 7 to 6
+	assert.Equal(t, byte(14), fileBytes[offsets[4]])
+	fileBytes[offsets[4]] = 12
+
+	report, err := Verify(bytes.NewReader(fileBytes), decodeLongRecord)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(142), report.Blocks)
+	assert.Equal(t, int64(993), report.Records)
+	assert.Equal(t, 1, len(report.Damaged))
+	assert.Equal(t, offsets[4], report.Damaged[0].Offset)
+	assert.Equal(t, offsets[5]-offsets[4], report.Damaged[0].Length)
+	assert.IsType(t, &RecordCountError{}, report.Damaged[0].Err)
+
+	// Without a decoder the record count can't be checked
+	report, err = Verify(bytes.NewReader(fileBytes), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(report.Damaged))
+}
+
+func TestSalvage(t *testing.T) {
+	fileBytes := writeRangeContainer(0, 1000, Deflate, 7, t)
+	offsets := blockOffsets(fileBytes, t)
+
+	// Corrupt the sync marker after the tenth block, which also loses the eleventh block
+	fileBytes[offsets[10]-1] ^= 0xff
+	// Truncate the file partway through the last block
+	fileBytes = fileBytes[:len(fileBytes)-10]
+
+	var salvaged bytes.Buffer
+	report, err := Salvage(bytes.NewReader(fileBytes), &salvaged, decodeLongRecord)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(140), report.Blocks)
+	assert.Equal(t, int64(980), report.Records)
+	assert.Equal(t, 2, len(report.Damaged))
+	assert.IsType(t, &SyncMarkerError{}, report.Damaged[0].Err)
+	assert.Equal(t, offsets[9], report.Damaged[0].Offset)
+	assert.Equal(t, offsets[11]-offsets[9], report.Damaged[0].Length)
+	assert.Equal(t, io.ErrUnexpectedEOF, report.Damaged[1].Err)
+	assert.Equal(t, int64(len(fileBytes))-offsets[142], report.Damaged[1].Length)
+
+	// The salvaged file should be valid, and hold every record outside the damaged blocks
+	report, err = Verify(bytes.NewReader(salvaged.Bytes()), decodeLongRecord)
+	assert.Nil(t, err)
+	assert.Equal(t, &VerifyReport{Blocks: 140, Records: 980}, report)
+
+	reader, err := NewReader(&salvaged)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected longRecord
+	for {
+		datumReader, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := decodeLongRecord(datumReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected == 9*7 {
+			expected = 11 * 7
+		}
+		assert.Equal(t, expected, record)
+		expected = expected + 1
+	}
+	assert.Equal(t, longRecord(994), expected)
+}
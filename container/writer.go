@@ -3,28 +3,17 @@ package container
 
 import (
 	"bytes"
-	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
 	"io"
+	"time"
 
 	"github.com/actgardner/gogen-avro/container/avro"
 )
 
-// A Codec specifies how the blocks within a container file should be compressed.
-type Codec string
-
-const (
-	// No compression
-	Null Codec = "null"
-	// Deflate compression
-	Deflate Codec = "deflate"
-	// Snappy compression
-	Snappy Codec = "snappy"
-)
-
-type CloseableResettableWriter interface {
-	Close() error
-	Reset(io.Writer)
-}
+// The magic bytes at the start of every OCF file
+var magicBytes = avro.Magic{'O', 'b', 'j', 1}
 
 // Writer wraps an io.Writer and writes the file and block-level framing required for an OCF file
 type Writer struct {
@@ -33,103 +22,348 @@ type Writer struct {
 	codec            Codec
 	recordsPerBlock  int64
 	blockBuffer      *bytes.Buffer
-	compressedWriter io.Writer
 	nextBlockRecords int64
+	metadata         map[string][]byte
+	blockSize        int
+	maxBlockAge      time.Duration
+	blockStarted     time.Time
+	pipeline         *compressionPipeline
+	closed           bool
+	schema           *writerSchema
+	branchBytes      [binary.MaxVarintLen64]byte
+	observer         WriterObserver
 }
 
 //  Create a new Writer wrapping the provided io.Writer with the given Codec and number of records per block.
 //  The Writer will lazily write the container file header when WriteRecord is called the first time.
-//  You must call Flush on the Writer before closing the underlying io.Writer, to ensure the final block is written.
+//  You must call Flush or Close on the Writer before closing the underlying io.Writer, to ensure the final block is written.
 //  A schema string must be passed to ensure that a correct header is written even if no records are written. This
 //  is required to produce valid empty Avro container files.
-func NewWriter(writer io.Writer, codec Codec, recordsPerBlock int64, schema string) (*Writer, error) {
+//  Each Writer generates a random sync marker, unless one is supplied with the WithSyncMarker option.
+//  An InvalidSchemaError is returned if the schema can't be parsed.
+func NewWriter(writer io.Writer, codec Codec, recordsPerBlock int64, schema string, opts ...WriterOption) (*Writer, error) {
+	var syncMarker [16]byte
+	_, err := rand.Read(syncMarker[:])
+	if err != nil {
+		return nil, err
+	}
+
+	avroWriter, err := newWriter(writer, codec, recordsPerBlock, schema, syncMarker, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = avroWriter.writeHeader(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return avroWriter, nil
+}
+
+// Create a new Writer which appends blocks to an existing container file, such as an *os.File opened for reading and writing.
+// The header of the file is read to find the codec and sync marker, and a SchemaMismatchError is returned if records with
+// the given schema can't be written to the file, using the same rules as WriteRecord.
+// The existing blocks are read to find the end of the last complete block. If the file ends with a partly written block,
+// for example because the process writing it crashed, the partial block is removed if the file has a Truncate method like
+// *os.File, and otherwise an IncompleteBlockError is returned. If a block before the end of the file is damaged, the
+// error reading it is returned.
+// Options which change the header, like WithSyncMarker and WithMetadata, can't be used when appending.
+func NewAppendWriter(file io.ReadWriteSeeker, recordsPerBlock int64, schema string, opts ...WriterOption) (*Writer, error) {
+	_, err := file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	avroWriter, err := newWriter(file, reader.Codec(), recordsPerBlock, reader.Schema(), reader.SyncMarker(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = avroWriter.schema.branch(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if avroWriter.syncMarker != reader.SyncMarker() || len(avroWriter.metadata) > 0 {
+		return nil, fmt.Errorf("Options which change the file header can't be used when appending")
+	}
+
+	end, err := appendOffset(file, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = file.Seek(end, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return avroWriter, nil
+}
+
+// Find the end of the last complete block in a file being appended to, removing a partial block at the end of the file if possible
+func appendOffset(file io.ReadSeeker, reader *Reader) (int64, error) {
+	for {
+		offset := reader.Offset()
+		_, err := reader.NextBlock()
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err == nil {
+			continue
+		}
+
+		// A block which was never finished isn't followed by another sync marker
+		if seekErr := reader.seek(file, offset); seekErr != nil {
+			return 0, seekErr
+		}
+
+		skipErr := reader.skipToSyncMarker()
+		if skipErr == nil {
+			return 0, err
+		}
+		if skipErr != io.EOF {
+			return 0, skipErr
+		}
+
+		truncater, ok := file.(interface {
+			Truncate(size int64) error
+		})
+		if !ok {
+			return 0, NewIncompleteBlockError(offset, err)
+		}
+		return offset, truncater.Truncate(offset)
+	}
+}
+
+// Create a Writer and apply the options, without writing a header
+func newWriter(writer io.Writer, codec Codec, recordsPerBlock int64, schema string, syncMarker [16]byte, opts []WriterOption) (*Writer, error) {
 	blockBytes := make([]byte, 0)
 	blockBuffer := bytes.NewBuffer(blockBytes)
 
+	parsedSchema, err := newWriterSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
 	avroWriter := &Writer{
 		writer:          writer,
-		syncMarker:      [16]byte{'g', 'o', 'g', 'e', 'n', 'a', 'v', 'r', 'o', 'm', 'a', 'g', 'i', 'c', '1', '0'},
+		syncMarker:      syncMarker,
 		codec:           codec,
 		recordsPerBlock: recordsPerBlock,
 		blockBuffer:     blockBuffer,
+		schema:          parsedSchema,
 	}
-	var err error
-	if codec == Deflate {
-		avroWriter.compressedWriter, err = flate.NewWriter(avroWriter.blockBuffer, flate.DefaultCompression)
+
+	for _, opt := range opts {
+		err := opt(avroWriter)
 		if err != nil {
 			return nil, err
 		}
-	} else if codec == Snappy {
-		avroWriter.compressedWriter = newSnappyWriter(avroWriter.blockBuffer)
-	} else {
-		avroWriter.compressedWriter = avroWriter.blockBuffer
 	}
 
-	err = avroWriter.writeHeader(schema)
-	if err != nil {
-		return nil, err
+	if avroWriter.pipeline != nil {
+		avroWriter.pipeline.observer = avroWriter.observer
 	}
-
 	return avroWriter, nil
 }
 
 func (avroWriter *Writer) writeHeader(schema string) error {
 	header := &avro.AvroContainerHeader{
-		Magic: [4]byte{'O', 'b', 'j', 1},
+		Magic: magicBytes,
 		Meta: map[string][]byte{
 			"avro.schema": []byte(schema),
-			"avro.codec":  []byte(avroWriter.codec),
+			"avro.codec":  []byte(avroWriter.codec.Name()),
 		},
 		Sync: avroWriter.syncMarker,
 	}
+	for k, v := range avroWriter.metadata {
+		header.Meta[k] = v
+	}
 	return header.Serialize(avroWriter.writer)
 }
 
 //  Write an AvroRecord to the container file. All gogen-avro generated structs
 //  fulfill the AvroRecord interface. Note that all records in a given container file
 //  must be of the same Avro type.
+//  The record's schema must match the schema in the file header, or if the header schema is a union,
+//  one of the union's branches. Otherwise a SchemaMismatchError is returned and the record isn't written.
 func (avroWriter *Writer) WriteRecord(record AvroRecord) error {
-	var err error
-	// Serialize the new record into the compressed writer
-	err = record.Serialize(avroWriter.compressedWriter)
+	if avroWriter.closed {
+		return ErrWriterClosed
+	}
+
+	branch, err := avroWriter.schema.branch(record.Schema())
 	if err != nil {
+		avroWriter.recordWritten(0, err)
 		return err
 	}
+
+	// Records in a file whose schema is a union are preceded by the index of their branch
+	recordStart := avroWriter.blockBuffer.Len()
+	if branch >= 0 {
+		n := binary.PutVarint(avroWriter.branchBytes[:], int64(branch))
+		avroWriter.blockBuffer.Write(avroWriter.branchBytes[:n])
+	}
+
+	// Serialize the new record into the block buffer, it's compressed when the block is flushed
+	err = record.Serialize(avroWriter.blockBuffer)
+	if err != nil {
+		// Drop anything written for the failed record, so the block only holds complete records
+		avroWriter.blockBuffer.Truncate(recordStart)
+		avroWriter.recordWritten(0, err)
+		return err
+	}
+	avroWriter.recordWritten(avroWriter.blockBuffer.Len()-recordStart, nil)
 	avroWriter.nextBlockRecords += 1
+	if avroWriter.nextBlockRecords == 1 {
+		avroWriter.blockStarted = time.Now()
+	}
 
-	// If the block if full, flush and reset the compressed writer,
-	// write the header and the block contents
-	if avroWriter.nextBlockRecords >= avroWriter.recordsPerBlock {
-		return avroWriter.Flush()
+	// If the block if full, compress and write the block contents
+	if avroWriter.blockFull() {
+		return avroWriter.writeBlock()
+	}
+
+	return nil
+}
+
+// Add records which have already been serialized, including the branch index if the schema is a union, to the current block
+func (avroWriter *Writer) writeSerialized(records []byte, count int64) error {
+	if avroWriter.closed {
+		return ErrWriterClosed
 	}
 
+	avroWriter.blockBuffer.Write(records)
+	if avroWriter.nextBlockRecords == 0 {
+		avroWriter.blockStarted = time.Now()
+	}
+	avroWriter.nextBlockRecords += count
+
+	if avroWriter.blockFull() {
+		return avroWriter.writeBlock()
+	}
 	return nil
 }
 
+// A block is full when it reaches the record limit, or the optional size or age limits
+func (avroWriter *Writer) blockFull() bool {
+	if avroWriter.nextBlockRecords >= avroWriter.recordsPerBlock {
+		return true
+	}
+	if avroWriter.blockSize > 0 && avroWriter.blockBuffer.Len() >= avroWriter.blockSize {
+		return true
+	}
+	if avroWriter.maxBlockAge > 0 && time.Since(avroWriter.blockStarted) >= avroWriter.maxBlockAge {
+		return true
+	}
+	return false
+}
+
 //  Write the current block to the file if it has been filled.  It is
 //  best-practise to always call this before the underlying io.Writer is closed.
+//  When blocks are compressed concurrently, Flush waits until every block has been
+//  written and returns the first error from any of them.
 func (avroWriter *Writer) Flush() error {
+	if avroWriter.closed {
+		return ErrWriterClosed
+	}
+
+	err := avroWriter.writeBlock()
+	if avroWriter.pipeline != nil {
+		pipelineErr := avroWriter.pipeline.drain()
+		if err == nil {
+			err = pipelineErr
+		}
+	}
+	return err
+}
+
+// Write a block of records which have already been serialized and compressed with the Writer's codec, such as a block
+// read with Reader.NextBlock from another file with the same schema and codec. Any records buffered by WriteRecord are
+// written first, so records stay in the order they were written. The block's sync marker is replaced with the Writer's.
+func (avroWriter *Writer) WriteBlock(block *avro.AvroContainerBlock) error {
+	err := avroWriter.Flush()
+	if err != nil {
+		return err
+	}
+
+	rewritten := *block
+	rewritten.Sync = avroWriter.syncMarker
+
+	writeStart := time.Now()
+	err = rewritten.Serialize(avroWriter.writer)
+	avroWriter.blockWritten(BlockStats{
+		Records:         block.NumRecords,
+		CompressedBytes: len(block.RecordBytes),
+		WriteTime:       time.Since(writeStart),
+		Err:             err,
+	})
+	return err
+}
+
+// Write the final block, stop any compression goroutines and release the block buffer. Returns the first error
+// from writing or compressing any block which hadn't already been returned by WriteRecord or Flush.
+// The underlying io.Writer is not closed. After Close, WriteRecord, Flush and Close return ErrWriterClosed.
+func (avroWriter *Writer) Close() error {
+	if avroWriter.closed {
+		return ErrWriterClosed
+	}
+
+	err := avroWriter.Flush()
+	avroWriter.closed = true
+	avroWriter.blockBuffer = nil
+	avroWriter.pipeline = nil
+	return err
+}
+
+// Compress and write the buffered records as a new block, or hand them to the compression pipeline
+func (avroWriter *Writer) writeBlock() error {
 	if avroWriter.nextBlockRecords == 0 {
 		return nil
 	}
 
-	// Write out all of the buffered records as a new block
-	// Must be called before closing to ensure the last block is written
-	if fwWriter, ok := avroWriter.compressedWriter.(CloseableResettableWriter); ok {
-		fwWriter.Close()
-		fwWriter.Reset(avroWriter.blockBuffer)
+	block := &avro.AvroContainerBlock{
+		NumRecords:  avroWriter.nextBlockRecords,
+		RecordBytes: avroWriter.blockBuffer.Bytes(),
+		Sync:        avroWriter.syncMarker,
 	}
 
-	if avroWriter.nextBlockRecords > 0 {
-		block := &avro.AvroContainerBlock{
-			NumRecords:  avroWriter.nextBlockRecords,
-			RecordBytes: avroWriter.blockBuffer.Bytes(),
-			Sync:        avroWriter.syncMarker,
-		}
-		err := block.Serialize(avroWriter.writer)
-		if err != nil {
-			return err
-		}
+	if avroWriter.pipeline != nil {
+		// The pipeline owns the buffered records now, so start a new buffer for the next block
+		avroWriter.blockBuffer = bytes.NewBuffer(make([]byte, 0, avroWriter.blockBuffer.Cap()))
+		avroWriter.nextBlockRecords = 0
+		return avroWriter.pipeline.submit(block)
+	}
+
+	stats := BlockStats{
+		Records:           block.NumRecords,
+		UncompressedBytes: len(block.RecordBytes),
+	}
+
+	var err error
+	compressStart := time.Now()
+	block.RecordBytes, err = avroWriter.codec.Compress(block.RecordBytes)
+	stats.CompressTime = time.Since(compressStart)
+	if err != nil {
+		stats.Err = err
+		avroWriter.blockWritten(stats)
+		return err
+	}
+
+	stats.CompressedBytes = len(block.RecordBytes)
+	writeStart := time.Now()
+	err = block.Serialize(avroWriter.writer)
+	stats.WriteTime = time.Since(writeStart)
+	stats.Err = err
+	avroWriter.blockWritten(stats)
+	if err != nil {
+		return err
 	}
 
 	avroWriter.blockBuffer.Reset()
@@ -137,3 +371,15 @@ func (avroWriter *Writer) Flush() error {
 
 	return nil
 }
+
+func (avroWriter *Writer) recordWritten(size int, err error) {
+	if avroWriter.observer != nil {
+		avroWriter.observer.RecordWritten(size, err)
+	}
+}
+
+func (avroWriter *Writer) blockWritten(stats BlockStats) {
+	if avroWriter.observer != nil {
+		avroWriter.observer.BlockWritten(stats)
+	}
+}
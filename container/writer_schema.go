@@ -0,0 +1,106 @@
+package container
+
+import (
+	"github.com/actgardner/gogen-avro/schema"
+)
+
+// writerSchema checks that records match the schema in the header of the file being written.
+// If the schema is a union, records can be any of the union's branches, and are written with the branch index.
+type writerSchema struct {
+	schema string
+	// The canonical form of the schema, or of each branch if the schema is a union
+	canonical string
+	branches  []string
+
+	// Record schemas are usually one of a few constant strings, so the branch for each schema string is cached
+	recordBranches map[string]int
+}
+
+// Compare two schemas by their Parsing Canonical Form, the same way records are checked against a writerSchema
+func schemasEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	aCanonical, err := canonicalForm(a)
+	if err != nil {
+		return false
+	}
+
+	bCanonical, err := canonicalForm(b)
+	if err != nil {
+		return false
+	}
+	return aCanonical == bCanonical
+}
+
+func canonicalForm(schemaJson string) (string, error) {
+	avroType, err := schema.Parse([]byte(schemaJson))
+	if err != nil {
+		return "", err
+	}
+	return schema.CanonicalForm(avroType)
+}
+
+func newWriterSchema(schemaJson string) (*writerSchema, error) {
+	avroType, err := schema.Parse([]byte(schemaJson))
+	if err != nil {
+		return nil, NewInvalidSchemaError(schemaJson, err)
+	}
+
+	w := &writerSchema{
+		schema:         schemaJson,
+		recordBranches: make(map[string]int),
+	}
+
+	if avroType.Kind() == schema.Union {
+		for _, branch := range avroType.Branches() {
+			canonical, err := schema.CanonicalForm(branch)
+			if err != nil {
+				return nil, NewInvalidSchemaError(schemaJson, err)
+			}
+			w.branches = append(w.branches, canonical)
+		}
+		return w, nil
+	}
+
+	w.canonical, err = schema.CanonicalForm(avroType)
+	if err != nil {
+		return nil, NewInvalidSchemaError(schemaJson, err)
+	}
+	return w, nil
+}
+
+// Find the union branch for a record with the given schema, or -1 if the file's schema isn't a union.
+// Returns a SchemaMismatchError if records with this schema can't be written to the file.
+func (w *writerSchema) branch(recordSchema string) (int, error) {
+	if branch, ok := w.recordBranches[recordSchema]; ok {
+		return branch, nil
+	}
+
+	canonical, err := canonicalForm(recordSchema)
+	if err != nil {
+		return 0, NewSchemaMismatchError(w.schema, recordSchema)
+	}
+
+	branch := -1
+	if w.branches == nil {
+		if canonical != w.canonical {
+			return 0, NewSchemaMismatchError(w.schema, recordSchema)
+		}
+	} else {
+		for i, b := range w.branches {
+			if canonical == b {
+				branch = i
+				break
+			}
+		}
+
+		if branch < 0 {
+			return 0, NewSchemaMismatchError(w.schema, recordSchema)
+		}
+	}
+
+	w.recordBranches[recordSchema] = branch
+	return branch, nil
+}
@@ -0,0 +1,294 @@
+package container
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A record with the schema "long", so the container can be tested without generated code
+type longRecord int64
+
+func (l longRecord) Schema() string {
+	return `"long"`
+}
+
+func (l longRecord) Serialize(w io.Writer) error {
+	// Avro longs use the same zig-zag varint encoding as encoding/binary
+	var buf [binary.MaxVarintLen64]byte
+	_, err := w.Write(buf[:binary.PutVarint(buf[:], int64(l))])
+	return err
+}
+
+// Reads a datum one byte at a time, so nothing after the datum is consumed
+type byteReader struct {
+	io.Reader
+}
+
+func (b byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(b.Reader, buf[:])
+	return buf[0], err
+}
+
+func decodeLongRecord(r io.Reader) (interface{}, error) {
+	value, err := binary.ReadVarint(byteReader{r})
+	return longRecord(value), err
+}
+
+// Write records start, start+1, ... to a container file with the given codec and block size, and close it
+func writeRangeContainer(start, count int, codec Codec, recordsPerBlock int64, t *testing.T, opts ...WriterOption) []byte {
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, codec, recordsPerBlock, longRecord(0).Schema(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := start; i < start+count; i++ {
+		if err = writer.WriteRecord(longRecord(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Read every record in a container file, and check they're the records written by writeRangeContainer
+func checkRangeContainer(fileBytes []byte, start, count int, t *testing.T) {
+	reader, err := NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := start
+	for {
+		datumReader, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := decodeLongRecord(datumReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, longRecord(i), record)
+		i = i + 1
+	}
+	assert.Equal(t, start+count, i)
+}
+
+func TestRandomSyncMarker(t *testing.T) {
+	first, err := NewReader(bytes.NewReader(writeRangeContainer(0, 5, Null, 2, t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewReader(bytes.NewReader(writeRangeContainer(0, 5, Null, 2, t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, first.SyncMarker(), second.SyncMarker())
+}
+
+func TestSuppliedSyncMarker(t *testing.T) {
+	syncMarker := [16]byte{'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'a', 'b', 'c', 'd', 'e', 'f'}
+	fileBytes := writeRangeContainer(0, 1, Null, 2, t, WithSyncMarker(syncMarker))
+
+	// The file should end with the supplied marker after the only block
+	assert.Equal(t, syncMarker[:], fileBytes[len(fileBytes)-16:])
+
+	reader, err := NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, syncMarker, [16]byte(reader.SyncMarker()))
+}
+
+func TestUserMetadata(t *testing.T) {
+	metadata := map[string][]byte{
+		"producer": []byte("ingest"),
+		"job.id":   []byte("1234"),
+	}
+
+	reader, err := NewReader(bytes.NewReader(writeRangeContainer(0, 0, Deflate, 2, t, WithMetadata(metadata))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte("ingest"), reader.Metadata()["producer"])
+	assert.Equal(t, []byte("1234"), reader.Metadata()["job.id"])
+	assert.Equal(t, Deflate, reader.Codec())
+	assert.Equal(t, longRecord(0).Schema(), reader.Schema())
+}
+
+func TestReservedUserMetadata(t *testing.T) {
+	_, err := NewWriter(ioutil.Discard, Null, 2, longRecord(0).Schema(), WithMetadata(map[string][]byte{"avro.codec": []byte("snappy")}))
+	assert.IsType(t, &ReservedMetadataKeyError{}, err)
+}
+
+func TestBlockSizeFlush(t *testing.T) {
+	syncMarker := [16]byte{'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'a', 'b', 'c', 'd', 'e', 'f'}
+	var buf bytes.Buffer
+	// Every record is larger than the block size, so each one should be flushed in its own block
+	writer, err := NewWriter(&buf, Null, math.MaxInt64, longRecord(0).Schema(), WithSyncMarker(syncMarker), WithBlockSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err = writer.WriteRecord(longRecord(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One sync marker in the header, then one per block
+	assert.Equal(t, 6, bytes.Count(buf.Bytes(), syncMarker[:]))
+}
+
+func TestMaxBlockAgeFlush(t *testing.T) {
+	syncMarker := [16]byte{'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'a', 'b', 'c', 'd', 'e', 'f'}
+	var buf bytes.Buffer
+	// The age is long enough that the block isn't flushed while the first records are written, even on a slow machine
+	writer, err := NewWriter(&buf, Null, 100, longRecord(0).Schema(), WithSyncMarker(syncMarker), WithMaxBlockAge(200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err = writer.WriteRecord(longRecord(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), syncMarker[:]))
+
+	// Sleeping past the age means the block is always flushed by the next record
+	time.Sleep(250 * time.Millisecond)
+	if err = writer.WriteRecord(longRecord(2)); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), syncMarker[:]))
+
+	reader, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := reader.NextBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(3), block.NumRecords)
+}
+
+func TestCompressionWorkers(t *testing.T) {
+	// Records should be read back in the order they were written
+	fileBytes := writeRangeContainer(0, 1000, Deflate, 3, t, WithCompressionWorkers(4, 2))
+	checkRangeContainer(fileBytes, 0, 1000, t)
+}
+
+// A writer which fails once more than limit bytes have been written
+type limitedWriter struct {
+	limit   int
+	written int
+}
+
+func (w *limitedWriter) Write(b []byte) (int, error) {
+	if w.written+len(b) > w.limit {
+		return 0, io.ErrShortWrite
+	}
+	w.written += len(b)
+	return len(b), nil
+}
+
+func TestCompressionWorkersError(t *testing.T) {
+	writer, err := NewWriter(&limitedWriter{limit: 1024}, Null, 1, longRecord(0).Schema(), WithCompressionWorkers(2, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		err = writer.WriteRecord(longRecord(i))
+		if err != nil {
+			break
+		}
+	}
+
+	assert.Equal(t, io.ErrShortWrite, writer.Flush())
+}
+
+func TestWriterClose(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, Deflate, 10, longRecord(0).Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		err = writer.WriteRecord(longRecord(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Close should write the final partial block
+	assert.Nil(t, writer.Close())
+
+	report, err := Verify(bytes.NewReader(buf.Bytes()), decodeLongRecord)
+	assert.Nil(t, err)
+	assert.Equal(t, &VerifyReport{Blocks: 1, Records: 5}, report)
+
+	assert.Equal(t, ErrWriterClosed, writer.WriteRecord(longRecord(5)))
+	assert.Equal(t, ErrWriterClosed, writer.Flush())
+	assert.Equal(t, ErrWriterClosed, writer.Close())
+}
+
+func TestWriterCloseCompressionWorkersError(t *testing.T) {
+	writer, err := NewWriter(&limitedWriter{limit: 1024}, Null, 1, longRecord(0).Schema(), WithCompressionWorkers(2, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		err = writer.WriteRecord(longRecord(i))
+		if err != nil {
+			break
+		}
+	}
+
+	var closer io.Closer = writer
+	assert.Equal(t, io.ErrShortWrite, closer.Close())
+	assert.Equal(t, ErrWriterClosed, writer.WriteRecord(longRecord(0)))
+}
+
+func TestWriterSchemaMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, Null, 10, `"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writer.WriteRecord(longRecord(0))
+	assert.IsType(t, &SchemaMismatchError{}, err)
+	assert.Nil(t, writer.Close())
+
+	report, err := Verify(bytes.NewReader(buf.Bytes()), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, &VerifyReport{}, report)
+}
+
+func TestWriterInvalidSchema(t *testing.T) {
+	_, err := NewWriter(ioutil.Discard, Null, 10, `{"type": "record", "name": "NoFields"}`)
+	assert.IsType(t, &InvalidSchemaError{}, err)
+
+	_, err = NewWriter(ioutil.Discard, Null, 10, `not json`)
+	assert.IsType(t, &InvalidSchemaError{}, err)
+}
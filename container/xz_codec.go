@@ -0,0 +1,41 @@
+package container
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/ulikunitz/xz"
+)
+
+type xzCodec struct{}
+
+func (xzCodec) Name() string {
+	return "xz"
+}
+
+func (xzCodec) Compress(block []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := xz.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = writer.Write(block)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (xzCodec) Decompress(block []byte) ([]byte, error) {
+	reader, err := xz.NewReader(bytes.NewReader(block))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(reader)
+}
@@ -0,0 +1,55 @@
+package container
+
+import (
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// The compression level used by the Zstandard codec
+const DefaultZstandardLevel = 3
+
+type zstandardCodec struct {
+	level    int
+	initOnce sync.Once
+	initErr  error
+	encoder  *zstd.Encoder
+	decoder  *zstd.Decoder
+}
+
+// Create a Zstandard codec with the given compression level, using the same scale as the zstd command line tool (1-22).
+func NewZstandardCodec(level int) Codec {
+	return &zstandardCodec{
+		level: level,
+	}
+}
+
+// The encoder and decoder start background goroutines, so they're only created once the codec is used
+func (z *zstandardCodec) init() error {
+	z.initOnce.Do(func() {
+		z.encoder, z.initErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(z.level)))
+		if z.initErr != nil {
+			return
+		}
+		z.decoder, z.initErr = zstd.NewReader(nil)
+	})
+	return z.initErr
+}
+
+func (z *zstandardCodec) Name() string {
+	return "zstandard"
+}
+
+func (z *zstandardCodec) Compress(block []byte) ([]byte, error) {
+	if err := z.init(); err != nil {
+		return nil, err
+	}
+	return z.encoder.EncodeAll(block, nil), nil
+}
+
+func (z *zstandardCodec) Decompress(block []byte) ([]byte, error) {
+	if err := z.init(); err != nil {
+		return nil, err
+	}
+	return z.decoder.DecodeAll(block, nil)
+}
@@ -8,6 +8,7 @@ package avro
 
 import (
 	"github.com/actgardner/gogen-avro/container"
+	"github.com/actgardner/gogen-avro/schema"
 	"io"
 )
 
@@ -19,13 +20,105 @@ type DemoSchema struct {
 	BytesField  []byte
 }
 
+// DemoSchemaReader reads DemoSchema records one at a time from an Avro object container file
+type DemoSchemaReader struct {
+	reader *container.Reader
+	// Converts records to this record's schema, if the file was written with a different schema
+	resolver *schema.Resolver
+	record   *DemoSchema
+	err      error
+}
+
 func DeserializeDemoSchema(r io.Reader) (*DemoSchema, error) {
 	return readDemoSchema(r)
 }
 
-func NewDemoSchemaWriter(writer io.Writer, codec container.Codec, recordsPerBlock int64) (*container.Writer, error) {
+func NewDemoSchemaAppendWriter(file io.ReadWriteSeeker, recordsPerBlock int64, opts ...container.WriterOption) (*container.Writer, error) {
+	str := &DemoSchema{}
+	return container.NewAppendWriter(file, recordsPerBlock, str.Schema(), opts...)
+}
+
+func NewDemoSchemaReader(reader io.Reader) (*DemoSchemaReader, error) {
+	containerReader, err := container.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
 	str := &DemoSchema{}
-	return container.NewWriter(writer, codec, recordsPerBlock, str.Schema())
+	err = containerReader.CheckSchema(str.Schema())
+	if err == nil {
+		return &DemoSchemaReader{reader: containerReader}, nil
+	}
+
+	// Files written with a different schema can still be read if their schema can be resolved to this one
+	resolver, resolveErr := schema.CachedResolver(containerReader.Schema(), str.Schema())
+	if resolveErr != nil {
+		return nil, err
+	}
+	return &DemoSchemaReader{reader: containerReader, resolver: resolver}, nil
+}
+
+func NewDemoSchemaRollingWriter(factory container.FileFactory, codec container.Codec, recordsPerBlock int64, opts ...container.RollingOption) (*container.RollingWriter, error) {
+	str := &DemoSchema{}
+	return container.NewRollingWriter(factory, codec, recordsPerBlock, str.Schema(), opts...)
+}
+
+func NewDemoSchemaWriter(writer io.Writer, codec container.Codec, recordsPerBlock int64, opts ...container.WriterOption) (*container.Writer, error) {
+	str := &DemoSchema{}
+	return container.NewWriter(writer, codec, recordsPerBlock, str.Schema(), opts...)
+}
+
+// The first error encountered while reading the file, or nil if the file was read successfully
+func (r *DemoSchemaReader) Err() error {
+	return r.err
+}
+
+// Read the next record from the file. Returns false when there are no records left or an error occurs, use Err to distinguish the two cases.
+func (r *DemoSchemaReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	datumReader, err := r.reader.Next()
+	if err == nil && r.resolver != nil {
+		datumReader, err = r.resolver.TranscodeReader(datumReader)
+	}
+
+	if err != nil {
+		if err != io.EOF {
+			r.err = err
+		}
+		r.record = nil
+		return false
+	}
+
+	r.record, r.err = readDemoSchema(datumReader)
+	return r.err == nil
+}
+
+// The record read by the most recent call to Next
+func (r *DemoSchemaReader) Record() *DemoSchema {
+	return r.record
+}
+
+// The Parsing Canonical Form of the schema, which the fingerprints are calculated from
+func (r *DemoSchema) CanonicalSchema() string {
+	return "{\"name\":\"DemoSchema\",\"type\":\"record\",\"fields\":[{\"name\":\"IntField\",\"type\":\"int\"},{\"name\":\"DoubleField\",\"type\":\"double\"},{\"name\":\"StringField\",\"type\":\"string\"},{\"name\":\"BoolField\",\"type\":\"boolean\"},{\"name\":\"BytesField\",\"type\":\"bytes\"}]}"
+}
+
+// The CRC-64-AVRO fingerprint of the schema's Parsing Canonical Form
+func (r *DemoSchema) Fingerprint64() uint64 {
+	return 0xad669bca04a956c4
+}
+
+// The MD5 fingerprint of the schema's Parsing Canonical Form
+func (r *DemoSchema) FingerprintMD5() [16]byte {
+	return [16]byte{0x87, 0x16, 0xf1, 0xa0, 0xec, 0x03, 0xb0, 0xce, 0xd9, 0x83, 0xd5, 0xab, 0x0c, 0x24, 0x25, 0x8b}
+}
+
+// The SHA-256 fingerprint of the schema's Parsing Canonical Form
+func (r *DemoSchema) FingerprintSHA256() [32]byte {
+	return [32]byte{0x54, 0xcb, 0xb9, 0x8b, 0xfd, 0x53, 0x48, 0x7a, 0x0a, 0x36, 0xda, 0x82, 0xda, 0xf6, 0xc7, 0x0b, 0xbb, 0xd9, 0xbc, 0x4b, 0x50, 0xbb, 0x92, 0x6a, 0x09, 0xb4, 0x2d, 0x43, 0xb1, 0x76, 0xe8, 0x3b}
 }
 
 func NewDemoSchema() *DemoSchema {
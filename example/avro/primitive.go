@@ -8,6 +8,7 @@ package avro
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"math"
 )
@@ -201,6 +202,13 @@ func readString(r io.Reader) (string, error) {
 	if err != nil {
 		return "", err
 	}
+
+	// makeslice can fail depending on available memory.
+	// We arbitrarily limit string size to sane default (~2.2GB).
+	if len < 0 || len > math.MaxInt32 {
+		return "", fmt.Errorf("string length out of range: %d", len)
+	}
+
 	bb := make([]byte, len)
 	_, err = io.ReadFull(r, bb)
 	if err != nil {
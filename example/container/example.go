@@ -41,10 +41,10 @@ func main() {
 		return
 	}
 
-	// Flush the buffers to ensure the last block has been written
-	err = containerWriter.Flush()
+	// Close the writer to ensure the last block has been written
+	err = containerWriter.Close()
 	if err != nil {
-		fmt.Printf("Error flushing last block to file: %v\n", err)
+		fmt.Printf("Error writing last block to file: %v\n", err)
 		return
 	}
 
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/actgardner/gogen-avro/container"
+	"github.com/actgardner/gogen-avro/schema"
+)
+
+// Subcommands for working with container files and schemas. Each takes the remaining command line arguments and returns the exit code.
+var commands = map[string]func(args []string) int{
+	"verify":    verifyCommand,
+	"salvage":   salvageCommand,
+	"concat":    concatCommand,
+	"tojson":    toJSONCommand,
+	"fromjson":  fromJSONCommand,
+	"getschema": getSchemaCommand,
+	"getmeta":   getMetaCommand,
+	"recodec":   recodecCommand,
+	"compat":    compatCommand,
+}
+
+// Create a DatumDecoder which reads records with the given schema, for files whose schema isn't known until they're opened
+func schemaDecoder(schemaJson string) (container.DatumDecoder, error) {
+	avroType, err := schema.Parse([]byte(schemaJson))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r io.Reader) (interface{}, error) {
+		return schema.ReadDatum(avroType, r)
+	}, nil
+}
+
+// Open a file for reading, or stdin if the name is "-"
+func openInput(fileName string) (io.ReadCloser, error) {
+	if fileName == "-" {
+		return os.Stdin, nil
+	}
+	return os.Open(fileName)
+}
+
+// Create a DatumDecoder for the schema in a file's header, and seek back to the start of the file
+func fileDecoder(file io.ReadSeeker) (container.DatumDecoder, error) {
+	reader, err := container.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	decode, err := schemaDecoder(reader.Schema())
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing schema - %v", err)
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	return decode, err
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/actgardner/gogen-avro/schema"
+)
+
+func compatCommand(args []string) int {
+	flags := flag.NewFlagSet("compat", flag.ExitOnError)
+	modeName := flags.String("mode", "BACKWARD", "The compatibility mode: BACKWARD, FORWARD, FULL, their _TRANSITIVE variants, or NONE")
+	flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool compat [--mode=<mode>] <schema files, oldest first>\n")
+		return 1
+	}
+
+	mode, err := schema.ParseCompatibilityMode(*modeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	fileNames := flags.Args()
+	versions := make([]*schema.Type, len(fileNames))
+	for i, fileName := range fileNames {
+		schemaJson, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file %q - %v\n", fileName, err)
+			return 2
+		}
+
+		versions[i], err = schema.Parse(schemaJson)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error decoding schema for file %q - %v\n", fileName, err)
+			return 2
+		}
+	}
+
+	latest := fileNames[len(fileNames)-1]
+	incompatibilities := schema.CheckCompatibility(mode, versions)
+	for _, i := range incompatibilities {
+		fmt.Printf("%v can't read data written with %v: %v: %v\n", fileNames[i.ReaderVersion], fileNames[i.WriterVersion], i.Path, i.Message)
+	}
+
+	if len(incompatibilities) > 0 {
+		fmt.Printf("%v is not %v compatible: %v incompatibilities\n", latest, mode, len(incompatibilities))
+		return 3
+	}
+
+	fmt.Printf("%v is %v compatible\n", latest, mode)
+	return 0
+}
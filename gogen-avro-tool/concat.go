@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/actgardner/gogen-avro/container"
+)
+
+func concatCommand(args []string) int {
+	flags := flag.NewFlagSet("concat", flag.ExitOnError)
+	flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool concat <output file> <input files>\n")
+		return 1
+	}
+
+	outputName := flags.Arg(0)
+	inputNames := flags.Args()[1:]
+
+	output, err := os.Create(outputName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating file %q - %v\n", outputName, err)
+		return 2
+	}
+
+	// Only one input is open at a time, so thousands of files can be merged without running out of file descriptors
+	inputs := make([]io.Reader, len(inputNames))
+	for i, name := range inputNames {
+		inputs[i] = &lazyFile{name: name}
+	}
+
+	err = container.Concat(output, inputs)
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		if inputErr, ok := err.(*container.InputError); ok {
+			err = fmt.Errorf("input file %q - %v", inputNames[inputErr.Input], inputErr.Err)
+		}
+		fmt.Fprintf(os.Stderr, "Error concatenating files - %v\n", err)
+		os.Remove(outputName)
+		return 3
+	}
+	return 0
+}
+
+// lazyFile opens a file on the first Read, and closes it once it has been read to the end
+type lazyFile struct {
+	name string
+	file *os.File
+	done bool
+}
+
+func (l *lazyFile) Read(p []byte) (int, error) {
+	if l.done {
+		return 0, io.EOF
+	}
+
+	if l.file == nil {
+		var err error
+		if l.file, err = os.Open(l.name); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := l.file.Read(p)
+	if err == io.EOF {
+		l.file.Close()
+		l.done = true
+	}
+	return n, err
+}
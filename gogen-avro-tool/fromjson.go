@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/actgardner/gogen-avro/container"
+	"github.com/actgardner/gogen-avro/schema"
+)
+
+func fromJSONCommand(args []string) int {
+	flags := flag.NewFlagSet("fromjson", flag.ExitOnError)
+	codecName := flags.String("codec", "null", "The codec used to compress blocks, one of null, deflate, snappy, zstandard, bzip2 or xz")
+	recordsPerBlock := flags.Int64("records-per-block", 1000, "The number of records in each block")
+	targetBytes := flags.Int("target-bytes", 0, "The uncompressed size in bytes at which blocks are written before they're full, or 0 for no size limit")
+	flags.Parse(args)
+
+	if flags.NArg() != 3 {
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool fromjson [--codec=<codec>] [--records-per-block=<records>] [--target-bytes=<bytes>] <schema file> <JSON file, or - for stdin> <output file>\n")
+		return 1
+	}
+
+	if *recordsPerBlock <= 0 {
+		fmt.Fprintf(os.Stderr, "Records per block must be positive, got %v\n", *recordsPerBlock)
+		return 1
+	}
+
+	if *targetBytes < 0 {
+		fmt.Fprintf(os.Stderr, "Target block size must not be negative, got %v\n", *targetBytes)
+		return 1
+	}
+
+	var opts []container.WriterOption
+	if *targetBytes > 0 {
+		opts = append(opts, container.WithBlockSize(*targetBytes))
+	}
+
+	codec, err := container.LookupCodec(*codecName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	schemaName := flags.Arg(0)
+	inputName := flags.Arg(1)
+	outputName := flags.Arg(2)
+
+	schemaJson, err := ioutil.ReadFile(schemaName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file %q - %v\n", schemaName, err)
+		return 2
+	}
+
+	encoder, err := newJSONRecordEncoder(string(schemaJson))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error decoding schema for file %q - %v\n", schemaName, err)
+		return 2
+	}
+
+	input, err := openInput(inputName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file %q - %v\n", inputName, err)
+		return 2
+	}
+	defer input.Close()
+
+	output, err := os.Create(outputName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating file %q - %v\n", outputName, err)
+		return 2
+	}
+
+	records, err := writeJSONRecords(output, input, encoder, codec, *recordsPerBlock, opts...)
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error converting file %q - %v\n", inputName, err)
+		os.Remove(outputName)
+		return 3
+	}
+
+	fmt.Printf("%v: wrote %v records\n", outputName, records)
+	return 0
+}
+
+// Write each line of input, a record in the Avro JSON encoding, to a new container file. Blank lines are skipped.
+func writeJSONRecords(output io.Writer, input io.Reader, encoder *jsonRecordEncoder, codec container.Codec, recordsPerBlock int64, opts ...container.WriterOption) (int64, error) {
+	writer, err := container.NewWriter(output, codec, recordsPerBlock, encoder.schema, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	lines := bufio.NewReader(input)
+	var records int64
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := lines.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return records, err
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			record, recordErr := encoder.decode(line)
+			if recordErr == nil {
+				recordErr = writer.WriteRecord(record)
+			}
+
+			if recordErr != nil {
+				return records, fmt.Errorf("line %v - %v", lineNumber, recordErr)
+			}
+			records += 1
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	return records, writer.Close()
+}
+
+// jsonRecordEncoder decodes JSON records with a schema into values which can be written with a container.Writer
+type jsonRecordEncoder struct {
+	schema   string
+	avroType *schema.Type
+	// If the schema is a union, records are written as the value of one of its branches, with that branch's schema
+	branches       []*schema.Type
+	branchSchemas  []string
+	branchesByName map[string]int
+}
+
+func newJSONRecordEncoder(schemaJson string) (*jsonRecordEncoder, error) {
+	avroType, err := schema.Parse([]byte(schemaJson))
+	if err != nil {
+		return nil, err
+	}
+
+	encoder := &jsonRecordEncoder{
+		schema:   schemaJson,
+		avroType: avroType,
+	}
+
+	if avroType.Kind() == schema.Union {
+		encoder.branches = avroType.Branches()
+		encoder.branchesByName = make(map[string]int)
+		for i, branch := range encoder.branches {
+			// The writer matches records to branches by canonical form
+			branchSchema, err := schema.CanonicalForm(branch)
+			if err != nil {
+				return nil, err
+			}
+
+			encoder.branchSchemas = append(encoder.branchSchemas, branchSchema)
+			encoder.branchesByName[branch.Name()] = i
+		}
+	}
+	return encoder, nil
+}
+
+func (e *jsonRecordEncoder) decode(line []byte) (*jsonRecord, error) {
+	datum, err := schema.DatumFromJSON(e.avroType, line)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.branches == nil {
+		return &jsonRecord{schema: e.schema, avroType: e.avroType, datum: datum}, nil
+	}
+
+	// DatumFromJSON returns nil for the null branch, or a map from the branch's name to its value
+	branch := e.branchesByName["null"]
+	for name, value := range asBranch(datum) {
+		branch = e.branchesByName[name]
+		datum = value
+	}
+	return &jsonRecord{schema: e.branchSchemas[branch], avroType: e.branches[branch], datum: datum}, nil
+}
+
+func asBranch(datum interface{}) map[string]interface{} {
+	branch, _ := datum.(map[string]interface{})
+	return branch
+}
+
+// jsonRecord implements container.AvroRecord for a generic datum
+type jsonRecord struct {
+	schema   string
+	avroType *schema.Type
+	datum    interface{}
+}
+
+func (r *jsonRecord) Schema() string {
+	return r.schema
+}
+
+func (r *jsonRecord) Serialize(w io.Writer) error {
+	return schema.WriteDatum(r.avroType, r.datum, w)
+}
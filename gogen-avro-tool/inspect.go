@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/actgardner/gogen-avro/container"
+)
+
+func getSchemaCommand(args []string) int {
+	flags := flag.NewFlagSet("getschema", flag.ExitOnError)
+	pretty := flags.Bool("pretty", false, "Whether to indent the schema over multiple lines")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool getschema [--pretty] <container file, or - for stdin>\n")
+		return 1
+	}
+
+	fileName := flags.Arg(0)
+	reader, file, err := openContainer(fileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file %q - %v\n", fileName, err)
+		return 2
+	}
+	defer file.Close()
+
+	err = writeSchema(os.Stdout, reader, *pretty)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error formatting schema for file %q - %v\n", fileName, err)
+		return 3
+	}
+	return 0
+}
+
+func getMetaCommand(args []string) int {
+	flags := flag.NewFlagSet("getmeta", flag.ExitOnError)
+	blocks := flags.Bool("blocks", true, "Whether to print the offset, record count and compressed size of every block")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool getmeta [--blocks=false] <container file, or - for stdin>\n")
+		return 1
+	}
+
+	fileName := flags.Arg(0)
+	reader, file, err := openContainer(fileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file %q - %v\n", fileName, err)
+		return 2
+	}
+	defer file.Close()
+
+	output := bufio.NewWriter(os.Stdout)
+	defer output.Flush()
+
+	err = writeMeta(output, reader, *blocks)
+	if err != nil {
+		output.Flush()
+		fmt.Fprintf(os.Stderr, "Error reading file %q - %v\n", fileName, err)
+		return 3
+	}
+	return 0
+}
+
+// Write the schema from a file's header, optionally indented over multiple lines
+func writeSchema(output io.Writer, reader *container.Reader, pretty bool) error {
+	schema := []byte(reader.Schema())
+	if pretty {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, schema, "", "  "); err != nil {
+			return err
+		}
+		schema = indented.Bytes()
+	}
+
+	_, err := fmt.Fprintf(output, "%s\n", schema)
+	return err
+}
+
+// Write the codec, sync marker and user metadata from a file's header, then read every block and write
+// the totals, and optionally the offset, record count and compressed size of each block
+func writeMeta(output io.Writer, reader *container.Reader, blocks bool) error {
+	syncMarker := reader.SyncMarker()
+	fmt.Fprintf(output, "codec: %v\n", reader.Codec().Name())
+	fmt.Fprintf(output, "sync marker: %x\n", syncMarker[:])
+
+	metadata := reader.UserMetadata()
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(output, "user metadata: %v keys\n", len(keys))
+	for _, k := range keys {
+		fmt.Fprintf(output, "  %v: %q\n", k, metadata[k])
+	}
+
+	var blockCount, records, compressedBytes int64
+	for {
+		offset := reader.Offset()
+		block, err := reader.NextBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Error reading block at offset %v - %v", offset, err)
+		}
+
+		if blocks {
+			fmt.Fprintf(output, "block %v: offset %v, %v records, %v bytes compressed\n", blockCount, offset, block.NumRecords, len(block.RecordBytes))
+		}
+
+		blockCount += 1
+		records += block.NumRecords
+		compressedBytes += int64(len(block.RecordBytes))
+	}
+
+	_, err := fmt.Fprintf(output, "total: %v blocks, %v records, %v bytes compressed\n", blockCount, records, compressedBytes)
+	return err
+}
+
+// Open a container file, or stdin if the name is "-", and read its header
+func openContainer(fileName string) (*container.Reader, io.Closer, error) {
+	file, err := openInput(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := container.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return reader, file, nil
+}
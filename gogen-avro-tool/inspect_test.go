@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/actgardner/gogen-avro/container"
+	"github.com/stretchr/testify/assert"
+)
+
+// testdata/primitives.avro holds 5 records of test/primitive's schema in blocks of 2, written with the null codec,
+// a sync marker of the bytes 0 to 15 and an "owner" metadata key
+const fixtureFile = "testdata/primitives.avro"
+
+const fixtureSchema = `{"fields":[{"name":"IntField","type":"int"},{"name":"LongField","type":"long"},{"name":"FloatField","type":"float"},{"name":"DoubleField","type":"double"},{"name":"StringField","type":"string"},{"name":"BoolField","type":"boolean"},{"name":"BytesField","type":"bytes"}],"name":"PrimitiveTestRecord","type":"record"}`
+
+func openFixture(t *testing.T) ([]byte, *container.Reader) {
+	fileBytes, err := ioutil.ReadFile(fixtureFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := container.NewReader(bytes.NewReader(fileBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileBytes, reader
+}
+
+func TestWriteSchema(t *testing.T) {
+	_, reader := openFixture(t)
+	var output bytes.Buffer
+	assert.Nil(t, writeSchema(&output, reader, false))
+	assert.Equal(t, fixtureSchema+"\n", output.String())
+
+	_, reader = openFixture(t)
+	output.Reset()
+	assert.Nil(t, writeSchema(&output, reader, true))
+	assert.Equal(t, `{
+  "fields": [
+    {
+      "name": "IntField",
+      "type": "int"
+    },
+    {
+      "name": "LongField",
+      "type": "long"
+    },
+    {
+      "name": "FloatField",
+      "type": "float"
+    },
+    {
+      "name": "DoubleField",
+      "type": "double"
+    },
+    {
+      "name": "StringField",
+      "type": "string"
+    },
+    {
+      "name": "BoolField",
+      "type": "boolean"
+    },
+    {
+      "name": "BytesField",
+      "type": "bytes"
+    }
+  ],
+  "name": "PrimitiveTestRecord",
+  "type": "record"
+}
+`, output.String())
+}
+
+func TestWriteMeta(t *testing.T) {
+	_, reader := openFixture(t)
+	var output bytes.Buffer
+	assert.Nil(t, writeMeta(&output, reader, true))
+	assert.Equal(t, `codec: null
+sync marker: 000102030405060708090a0b0c0d0e0f
+user metadata: 1 keys
+  owner: "tests"
+block 0: offset 379, 2 records, 46 bytes compressed
+block 1: offset 443, 2 records, 46 bytes compressed
+block 2: offset 507, 1 records, 23 bytes compressed
+total: 3 blocks, 5 records, 115 bytes compressed
+`, output.String())
+}
+
+func TestWriteMetaNoBlocks(t *testing.T) {
+	_, reader := openFixture(t)
+	var output bytes.Buffer
+	assert.Nil(t, writeMeta(&output, reader, false))
+	assert.Equal(t, `codec: null
+sync marker: 000102030405060708090a0b0c0d0e0f
+user metadata: 1 keys
+  owner: "tests"
+total: 3 blocks, 5 records, 115 bytes compressed
+`, output.String())
+}
+
+func TestWriteMetaTruncated(t *testing.T) {
+	fileBytes, _ := openFixture(t)
+	reader, err := container.NewReader(bytes.NewReader(fileBytes[:len(fileBytes)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	err = writeMeta(&output, reader, true)
+	assert.Contains(t, err.Error(), "Error reading block at offset 507")
+	assert.Contains(t, output.String(), "block 1: offset 443, 2 records, 46 bytes compressed\n")
+}
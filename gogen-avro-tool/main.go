@@ -0,0 +1,34 @@
+// gogen-avro-tool works with Avro container files and schemas without generated code. It's a separate command from
+// gogen-avro, so the code generator's arguments are never mistaken for a subcommand.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(1)
+	}
+	os.Exit(command(os.Args[2:]))
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool <command> [arguments]\nCommands: %v\n", strings.Join(names, ", "))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/actgardner/gogen-avro/container"
+)
+
+func recodecCommand(args []string) int {
+	flags := flag.NewFlagSet("recodec", flag.ExitOnError)
+	codecName := flags.String("codec", "deflate", "The codec used to compress blocks in the output, one of null, deflate, snappy, zstandard, bzip2 or xz")
+	recordsPerBlock := flags.Int64("records-per-block", 0, "The number of records in each block of the output, or 0 to keep input blocks whole")
+	targetBytes := flags.Int("target-bytes", 0, "The uncompressed size in bytes at which output blocks are written, or 0 for no size limit")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool recodec [--codec=<codec>] [--records-per-block=<records>] [--target-bytes=<bytes>] <input file> <output file>\n")
+		return 1
+	}
+
+	if *recordsPerBlock < 0 {
+		fmt.Fprintf(os.Stderr, "Records per block must not be negative, got %v\n", *recordsPerBlock)
+		return 1
+	}
+
+	if *targetBytes < 0 {
+		fmt.Fprintf(os.Stderr, "Target block size must not be negative, got %v\n", *targetBytes)
+		return 1
+	}
+
+	var opts []container.WriterOption
+	if *targetBytes > 0 {
+		opts = append(opts, container.WithBlockSize(*targetBytes))
+	}
+
+	codec, err := container.LookupCodec(*codecName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	inputName := flags.Arg(0)
+	outputName := flags.Arg(1)
+
+	input, err := os.Open(inputName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file %q - %v\n", inputName, err)
+		return 2
+	}
+	defer input.Close()
+
+	// Records are only decoded to find where they end when they're regrouped into new blocks
+	var decode container.DatumDecoder
+	if *recordsPerBlock > 0 {
+		decode, err = fileDecoder(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file %q - %v\n", inputName, err)
+			return 2
+		}
+	}
+
+	output, err := os.Create(outputName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating file %q - %v\n", outputName, err)
+		return 2
+	}
+
+	err = container.Recodec(output, input, codec, *recordsPerBlock, decode, opts...)
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error recompressing file %q - %v\n", inputName, err)
+		os.Remove(outputName)
+		return 3
+	}
+	return 0
+}
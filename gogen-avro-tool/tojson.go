@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/actgardner/gogen-avro/container"
+	"github.com/actgardner/gogen-avro/schema"
+)
+
+func toJSONCommand(args []string) int {
+	flags := flag.NewFlagSet("tojson", flag.ExitOnError)
+	pretty := flags.Bool("pretty", false, "Whether to indent each record over multiple lines")
+	head := flags.Int64("head", -1, "The maximum number of records to print, or -1 for every record")
+	count := flags.Bool("count", false, "Print the number of records in the file instead of the records")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool tojson [--pretty] [--head=<records>] [--count] <container file, or - for stdin>\n")
+		return 1
+	}
+
+	fileName := flags.Arg(0)
+	file, err := openInput(fileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file %q - %v\n", fileName, err)
+		return 2
+	}
+	defer file.Close()
+
+	reader, err := container.NewReader(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file %q - %v\n", fileName, err)
+		return 2
+	}
+
+	output := bufio.NewWriter(os.Stdout)
+	defer output.Flush()
+
+	if *count {
+		records, err := countRecords(reader, *head)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file %q - %v\n", fileName, err)
+			return 3
+		}
+		fmt.Fprintf(output, "%v\n", records)
+		return 0
+	}
+
+	avroType, err := schema.Parse([]byte(reader.Schema()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing schema for file %q - %v\n", fileName, err)
+		return 2
+	}
+
+	for i := int64(0); *head < 0 || i < *head; i++ {
+		datumReader, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err == nil {
+			err = writeJSONRecord(output, avroType, datumReader, *pretty)
+		}
+
+		if err != nil {
+			output.Flush()
+			fmt.Fprintf(os.Stderr, "Error reading record %v from file %q - %v\n", i, fileName, err)
+			return 3
+		}
+	}
+	return 0
+}
+
+func writeJSONRecord(output io.Writer, avroType *schema.Type, datumReader io.Reader, pretty bool) error {
+	datum, err := schema.ReadDatum(avroType, datumReader)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := schema.DatumToJSON(avroType, datum)
+	if err != nil {
+		return err
+	}
+
+	if pretty {
+		var indented bytes.Buffer
+		if err = json.Indent(&indented, encoded, "", "  "); err != nil {
+			return err
+		}
+		encoded = indented.Bytes()
+	}
+
+	encoded = append(encoded, '\n')
+	_, err = output.Write(encoded)
+	return err
+}
+
+// Count the records in the file from the block headers, without decoding them
+func countRecords(reader *container.Reader, limit int64) (int64, error) {
+	var records int64
+	for limit < 0 || records < limit {
+		block, err := reader.NextBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return records, err
+		}
+		records += block.NumRecords
+	}
+
+	if limit >= 0 && records > limit {
+		records = limit
+	}
+	return records, nil
+}
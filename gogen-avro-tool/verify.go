@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/actgardner/gogen-avro/container"
+)
+
+func verifyCommand(args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	checkRecords := flags.Bool("check-records", true, "Whether to decode every record to check the record count of each block")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool verify [--check-records=false] <container files>\n")
+		return 1
+	}
+
+	exitCode := 0
+	for _, fileName := range flags.Args() {
+		report, err := verifyFile(fileName, *checkRecords, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error verifying file %q - %v\n", fileName, err)
+			exitCode = 2
+			continue
+		}
+
+		printReport(fileName, report)
+		if len(report.Damaged) > 0 {
+			exitCode = 3
+		}
+	}
+	return exitCode
+}
+
+func salvageCommand(args []string) int {
+	flags := flag.NewFlagSet("salvage", flag.ExitOnError)
+	checkRecords := flags.Bool("check-records", true, "Whether to decode every record, and drop blocks whose record count is wrong")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro-tool salvage [--check-records=false] <damaged file> <output file>\n")
+		return 1
+	}
+
+	inputName := flags.Arg(0)
+	outputName := flags.Arg(1)
+	output, err := os.Create(outputName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating file %q - %v\n", outputName, err)
+		return 2
+	}
+
+	report, err := verifyFile(inputName, *checkRecords, output)
+	if err == nil {
+		err = output.Close()
+	} else {
+		output.Close()
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error salvaging file %q - %v\n", inputName, err)
+		return 2
+	}
+
+	printReport(inputName, report)
+	return 0
+}
+
+// Verify a file, or salvage it into output if output is not nil
+func verifyFile(fileName string, checkRecords bool, output io.Writer) (*container.VerifyReport, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var decode container.DatumDecoder
+	if checkRecords {
+		if decode, err = fileDecoder(file); err != nil {
+			return nil, err
+		}
+	}
+
+	if output != nil {
+		return container.Salvage(file, output, decode)
+	}
+	return container.Verify(file, decode)
+}
+
+func printReport(fileName string, report *container.VerifyReport) {
+	for _, damaged := range report.Damaged {
+		fmt.Printf("%v: dropped %v bytes at offset %v - %v\n", fileName, damaged.Length, damaged.Offset, damaged.Err)
+	}
+	fmt.Printf("%v: %v valid blocks containing %v records, %v damaged regions\n", fileName, report.Blocks, report.Records, len(report.Damaged))
+}
@@ -15,12 +15,14 @@ import (
 func main() {
 	packageName := flag.String("package", "avro", "Name of generated package")
 	containers := flag.Bool("containers", false, "Whether to generate container writer methods")
+	fromSchema := flag.Bool("from-schema", false, "Whether to generate methods which deserialize records written with a different schema")
+	singleObject := flag.Bool("single-object", false, "Whether to generate methods for the single-object encoding")
 	definitionCompareOnlyName := flag.Bool("onlyname", false, "In case, we would like to check only the name and namespace of the schema")
 	shortUnions := flag.Bool("short-unions", false, "Whether to use shorter names for Union types")
 
 	flag.Parse()
 	if flag.NArg() < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: gogen-avro [--short-unions] [--package=<package name>] [--containers] <target directory> <schema files>\n")
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro [--short-unions] [--package=<package name>] [--containers] [--from-schema] [--single-object] <target directory> <schema files>\n")
 		os.Exit(1)
 	}
 
@@ -45,7 +47,7 @@ func main() {
 		}
 	}
 
-	err = namespace.AddToPackage(pkg, codegenComment(files), *containers)
+	err = namespace.AddToPackage(pkg, codegenComment(files), *containers, *fromSchema, *singleObject)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating code for schema - %v\n", err)
 		os.Exit(4)
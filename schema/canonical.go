@@ -0,0 +1,30 @@
+package schema
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+
+	"github.com/actgardner/gogen-avro/types"
+)
+
+// Write a type in Parsing Canonical Form. Named types are written with their full names, and only the attributes which
+// affect the binary encoding are kept. The form is written by the types package, so it's the same as the CanonicalSchema
+// method of generated records.
+func CanonicalForm(t *Type) (string, error) {
+	return types.CanonicalForm(t.avroType)
+}
+
+// The CRC-64-AVRO (Rabin) fingerprint of a schema's canonical form
+func Fingerprint64(canonicalForm []byte) uint64 {
+	return types.Fingerprint64(canonicalForm)
+}
+
+// The MD5 fingerprint of a schema's canonical form
+func FingerprintMD5(canonicalForm []byte) [md5.Size]byte {
+	return types.FingerprintMD5(canonicalForm)
+}
+
+// The SHA-256 fingerprint of a schema's canonical form
+func FingerprintSHA256(canonicalForm []byte) [sha256.Size]byte {
+	return types.FingerprintSHA256(canonicalForm)
+}
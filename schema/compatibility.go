@@ -0,0 +1,112 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompatibilityMode is a schema registry style rule for which versions of a schema a new version must be compatible with
+type CompatibilityMode int
+
+const (
+	// The new schema can read data written with the previous version
+	Backward CompatibilityMode = iota
+	// The new schema can read data written with every previous version
+	BackwardTransitive
+	// Data written with the new schema can be read with the previous version
+	Forward
+	// Data written with the new schema can be read with every previous version
+	ForwardTransitive
+	// Both Backward and Forward
+	Full
+	// Both BackwardTransitive and ForwardTransitive
+	FullTransitive
+	// No checks
+	None
+)
+
+var compatibilityModeNames = map[CompatibilityMode]string{
+	Backward:           "BACKWARD",
+	BackwardTransitive: "BACKWARD_TRANSITIVE",
+	Forward:            "FORWARD",
+	ForwardTransitive:  "FORWARD_TRANSITIVE",
+	Full:               "FULL",
+	FullTransitive:     "FULL_TRANSITIVE",
+	None:               "NONE",
+}
+
+func (m CompatibilityMode) String() string {
+	if name, ok := compatibilityModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("CompatibilityMode(%d)", int(m))
+}
+
+// Find a CompatibilityMode by its name, like BACKWARD or FULL_TRANSITIVE. Names are case-insensitive.
+func ParseCompatibilityMode(name string) (CompatibilityMode, error) {
+	for mode, modeName := range compatibilityModeNames {
+		if strings.EqualFold(name, modeName) {
+			return mode, nil
+		}
+	}
+	return None, fmt.Errorf("Unknown compatibility mode %q", name)
+}
+
+func (m CompatibilityMode) backward() bool {
+	return m == Backward || m == BackwardTransitive || m == Full || m == FullTransitive
+}
+
+func (m CompatibilityMode) forward() bool {
+	return m == Forward || m == ForwardTransitive || m == Full || m == FullTransitive
+}
+
+func (m CompatibilityMode) transitive() bool {
+	return m == BackwardTransitive || m == ForwardTransitive || m == FullTransitive
+}
+
+// Incompatibility describes a type in one version of a schema which can't be resolved from another version
+type Incompatibility struct {
+	// The indexes of the versions used to write and read the data
+	WriterVersion int
+	ReaderVersion int
+	// The path to the type in the reader schema, and a description of the problem
+	Path    string
+	Message string
+}
+
+// Check the last of a list of versions of a schema, ordered from oldest to newest, against the earlier versions.
+// Returns every incompatibility the mode doesn't allow, or an empty slice if the new version is compatible.
+func CheckCompatibility(mode CompatibilityMode, versions []*Type) []Incompatibility {
+	incompatibilities := make([]Incompatibility, 0)
+	if len(versions) < 2 {
+		return incompatibilities
+	}
+
+	latest := len(versions) - 1
+	oldest := latest - 1
+	if mode.transitive() {
+		oldest = 0
+	}
+
+	for previous := latest - 1; previous >= oldest; previous-- {
+		if mode.backward() {
+			incompatibilities = appendIncompatibilities(incompatibilities, versions, previous, latest)
+		}
+		if mode.forward() {
+			incompatibilities = appendIncompatibilities(incompatibilities, versions, latest, previous)
+		}
+	}
+	return incompatibilities
+}
+
+func appendIncompatibilities(incompatibilities []Incompatibility, versions []*Type, writer, reader int) []Incompatibility {
+	for _, err := range ResolutionErrors(versions[writer], versions[reader]) {
+		incompatibilities = append(incompatibilities, Incompatibility{
+			WriterVersion: writer,
+			ReaderVersion: reader,
+			Path:          err.Path,
+			Message:       err.Message,
+		})
+	}
+	return incompatibilities
+}
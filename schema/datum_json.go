@@ -0,0 +1,468 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+  Conversion between the generic values used by ReadDatum and WriteDatum and the Avro JSON encoding.
+
+  Records are written as objects with their fields in schema order, bytes and fixed values are written as strings
+  where each byte is one code point (ISO-8859-1), and non-null union values are written as an object with a single key,
+  the name of the branch's type. Float and double values which aren't finite are written as the strings "NaN", "Infinity"
+  and "-Infinity", since JSON has no representation for them.
+
+  When decoding, record fields which are missing from the JSON take the default value from the schema, if it has one.
+  Defaults in the schema leave out the object naming the branch of union values, so they're matched to a branch by type.
+*/
+
+// Encode a datum of the given type, in the form returned by ReadDatum, in the Avro JSON encoding
+func DatumToJSON(t *Type, datum interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := appendJSON(&buf, t, datum); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func appendJSON(buf *bytes.Buffer, t *Type, datum interface{}) error {
+	switch t.kind {
+	case Null:
+		if datum != nil {
+			return wrongDatumType(t, datum)
+		}
+		buf.WriteString("null")
+		return nil
+	case Boolean:
+		if _, ok := datum.(bool); !ok {
+			return wrongDatumType(t, datum)
+		}
+		return appendMarshaledJSON(buf, datum)
+	case Int:
+		if _, ok := datum.(int32); !ok {
+			return wrongDatumType(t, datum)
+		}
+		return appendMarshaledJSON(buf, datum)
+	case Long:
+		if _, ok := datum.(int64); !ok {
+			return wrongDatumType(t, datum)
+		}
+		return appendMarshaledJSON(buf, datum)
+	case Float:
+		f, ok := datum.(float32)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		return appendFloatJSON(buf, float64(f), f)
+	case Double:
+		f, ok := datum.(float64)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		return appendFloatJSON(buf, f, f)
+	case Bytes:
+		bb, ok := datum.([]byte)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		return appendBytesJSON(buf, bb)
+	case String:
+		s, ok := datum.(string)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		return appendMarshaledJSON(buf, s)
+	case Record:
+		record, ok := datum.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Expected a map[string]interface{} for record %v, got %T", t.name, datum)
+		}
+
+		buf.WriteByte('{')
+		for i, f := range t.fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := appendMarshaledJSON(buf, f.name); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := appendJSON(buf, f.t, record[f.name]); err != nil {
+				return fmt.Errorf("Error encoding field %q of record %v: %v", f.name, t.name, err)
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case Enum:
+		symbol, ok := datum.(string)
+		if !ok {
+			return fmt.Errorf("Expected a string for enum %v, got %T", t.name, datum)
+		}
+		return appendMarshaledJSON(buf, symbol)
+	case Fixed:
+		bb, ok := datum.([]byte)
+		if !ok {
+			return fmt.Errorf("Expected a []byte for fixed %v, got %T", t.name, datum)
+		}
+		return appendBytesJSON(buf, bb)
+	case Array:
+		items, ok := datum.([]interface{})
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+
+		buf.WriteByte('[')
+		for i, item := range items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := appendJSON(buf, t.items, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case Map:
+		items, ok := datum.(map[string]interface{})
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+
+		// Sort the keys so the output is the same every time
+		keys := make([]string, 0, len(items))
+		for k := range items {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := appendMarshaledJSON(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := appendJSON(buf, t.items, items[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case Union:
+		return appendUnionJSON(buf, t, datum)
+	}
+	return fmt.Errorf("Unable to encode datum of type %v", t.Name())
+}
+
+func appendUnionJSON(buf *bytes.Buffer, t *Type, datum interface{}) error {
+	i, value, err := unionBranch(t, datum)
+	if err != nil {
+		return err
+	}
+
+	branch := t.branches[i]
+	if branch.kind == Null {
+		buf.WriteString("null")
+		return nil
+	}
+
+	buf.WriteByte('{')
+	if err := appendMarshaledJSON(buf, branch.Name()); err != nil {
+		return err
+	}
+	buf.WriteByte(':')
+	if err := appendJSON(buf, branch, value); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func appendFloatJSON(buf *bytes.Buffer, f float64, datum interface{}) error {
+	switch {
+	case math.IsNaN(f):
+		buf.WriteString(`"NaN"`)
+	case math.IsInf(f, 1):
+		buf.WriteString(`"Infinity"`)
+	case math.IsInf(f, -1):
+		buf.WriteString(`"-Infinity"`)
+	default:
+		return appendMarshaledJSON(buf, datum)
+	}
+	return nil
+}
+
+// Bytes are encoded as a string with one code point per byte
+func appendBytesJSON(buf *bytes.Buffer, bb []byte) error {
+	var s strings.Builder
+	for _, b := range bb {
+		s.WriteRune(rune(b))
+	}
+	return appendMarshaledJSON(buf, s.String())
+}
+
+func appendMarshaledJSON(buf *bytes.Buffer, v interface{}) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(encoded)
+	return nil
+}
+
+// Decode a datum of the given type from the Avro JSON encoding, to the form used by WriteDatum
+func DatumFromJSON(t *Type, data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Keep numbers as json.Number so longs aren't rounded to a float64
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if decoder.More() {
+		return nil, fmt.Errorf("Unexpected data after JSON value")
+	}
+	return datumFromJSON(t, value)
+}
+
+func datumFromJSON(t *Type, value interface{}) (interface{}, error) {
+	switch t.kind {
+	case Null:
+		if value != nil {
+			return nil, wrongJSONType(t, value)
+		}
+		return nil, nil
+	case Boolean:
+		if _, ok := value.(bool); !ok {
+			return nil, wrongJSONType(t, value)
+		}
+		return value, nil
+	case Int:
+		i, err := integerFromJSON(t, value)
+		if err != nil {
+			return nil, err
+		}
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("int value out of range: %d", i)
+		}
+		return int32(i), nil
+	case Long:
+		return integerFromJSON(t, value)
+	case Float:
+		f, err := floatFromJSON(t, value)
+		if err != nil {
+			return nil, err
+		}
+		return float32(f), nil
+	case Double:
+		return floatFromJSON(t, value)
+	case Bytes:
+		return bytesFromJSON(t, value)
+	case String:
+		if _, ok := value.(string); !ok {
+			return nil, wrongJSONType(t, value)
+		}
+		return value, nil
+	case Record:
+		return recordFromJSON(t, value, datumFromJSON)
+	case Enum:
+		symbol, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("Expected a string for enum %v, got %v", t.name, value)
+		}
+
+		if !hasSymbol(t, symbol) {
+			return nil, fmt.Errorf("Invalid symbol %q for enum %v", symbol, t.name)
+		}
+		return symbol, nil
+	case Fixed:
+		bb, err := bytesFromJSON(t, value)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(bb) != t.size {
+			return nil, fmt.Errorf("Expected %v bytes for fixed %v, got %v", t.size, t.name, len(bb))
+		}
+		return bb, nil
+	case Array:
+		return arrayFromJSON(t, value, datumFromJSON)
+	case Map:
+		return mapFromJSON(t, value, datumFromJSON)
+	case Union:
+		i, branchValue, err := unionBranch(t, value)
+		if err != nil {
+			return nil, err
+		}
+
+		branch := t.branches[i]
+		if branch.kind == Null {
+			return nil, nil
+		}
+
+		datum, err := datumFromJSON(branch, branchValue)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{branch.Name(): datum}, nil
+	}
+	return nil, fmt.Errorf("Unable to decode datum of type %v", t.Name())
+}
+
+// Decode a record, converting the value of each field with decode. Fields which are missing take their default value.
+func recordFromJSON(t *Type, value interface{}, decode func(*Type, interface{}) (interface{}, error)) (interface{}, error) {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected an object for record %v, got %v", t.name, value)
+	}
+
+	record := make(map[string]interface{}, len(t.fields))
+	for _, f := range t.fields {
+		var datum interface{}
+		var err error
+		if fieldValue, ok := fields[f.name]; ok {
+			datum, err = decode(f.t, fieldValue)
+		} else if f.hasDefault {
+			datum, err = defaultDatum(f.t, f.defaultValue)
+		} else {
+			return nil, fmt.Errorf("Missing field %q of record %v, which has no default", f.name, t.name)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding field %q of record %v: %v", f.name, t.name, err)
+		}
+		record[f.name] = datum
+	}
+
+	for name := range fields {
+		if _, ok := record[name]; !ok {
+			return nil, fmt.Errorf("Record %v has no field %q", t.name, name)
+		}
+	}
+	return record, nil
+}
+
+func arrayFromJSON(t *Type, value interface{}, decode func(*Type, interface{}) (interface{}, error)) (interface{}, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, wrongJSONType(t, value)
+	}
+
+	datum := make([]interface{}, len(items))
+	for i, item := range items {
+		var err error
+		if datum[i], err = decode(t.items, item); err != nil {
+			return nil, err
+		}
+	}
+	return datum, nil
+}
+
+func mapFromJSON(t *Type, value interface{}, decode func(*Type, interface{}) (interface{}, error)) (interface{}, error) {
+	items, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, wrongJSONType(t, value)
+	}
+
+	datum := make(map[string]interface{}, len(items))
+	for k, item := range items {
+		var err error
+		if datum[k], err = decode(t.items, item); err != nil {
+			return nil, err
+		}
+	}
+	return datum, nil
+}
+
+// Convert a default value from the schema to a datum of the given type. Defaults are in the JSON encoding, except that
+// union values aren't wrapped in an object naming the branch, at any depth. The default of a union must be a value of
+// the union's first branch.
+func defaultDatum(t *Type, value interface{}) (interface{}, error) {
+	switch t.kind {
+	case Union:
+		if len(t.branches) == 0 {
+			return nil, fmt.Errorf("Default value %v for an empty union", value)
+		}
+
+		branch := t.branches[0]
+		datum, err := defaultDatum(branch, value)
+		if err != nil {
+			return nil, fmt.Errorf("Default value %v doesn't match the first branch of the union, %v", value, branch.Name())
+		}
+
+		if branch.kind == Null {
+			return nil, nil
+		}
+		return map[string]interface{}{branch.Name(): datum}, nil
+	case Record:
+		return recordFromJSON(t, value, defaultDatum)
+	case Array:
+		return arrayFromJSON(t, value, defaultDatum)
+	case Map:
+		return mapFromJSON(t, value, defaultDatum)
+	}
+	return datumFromJSON(t, value)
+}
+
+func integerFromJSON(t *Type, value interface{}) (int64, error) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, wrongJSONType(t, value)
+	}
+
+	i, err := strconv.ParseInt(string(n), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid %v value %v", t.Name(), n)
+	}
+	return i, nil
+}
+
+func floatFromJSON(t *Type, value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Float64()
+	case string:
+		switch v {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+	}
+	return 0, wrongJSONType(t, value)
+}
+
+// Bytes are decoded from a string with one code point per byte
+func bytesFromJSON(t *Type, value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("Expected a string for %v, got %v", t.Name(), value)
+	}
+
+	bb := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, fmt.Errorf("Invalid code point %U in %v value, expected one code point per byte", r, t.Name())
+		}
+		bb = append(bb, byte(r))
+	}
+	return bb, nil
+}
+
+func wrongJSONType(t *Type, value interface{}) error {
+	return fmt.Errorf("Unexpected JSON value %v for %v", value, t.Name())
+}
@@ -0,0 +1,218 @@
+package schema
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+/*
+  Schema-driven decoding of Avro binary data, for tools which need to read any schema without generating code.
+
+  Datums are decoded to generic Go values:
+    - null is nil, boolean is bool, int is int32, long is int64, float is float32 and double is float64
+    - bytes and fixed are []byte, string is string, and enums are the symbol as a string
+    - arrays are []interface{}, and maps and records are map[string]interface{}
+    - unions are nil for the null branch, or a map[string]interface{} from the branch's type name to the value
+*/
+
+// Read a single datum of the given type from r
+func ReadDatum(t *Type, r io.Reader) (interface{}, error) {
+	byteReader, ok := r.(io.ByteReader)
+	if !ok {
+		// Wrapping r in a bufio.Reader would read ahead past the end of the datum
+		byteReader = &singleByteReader{r}
+	}
+	return readDatum(t, &datumReader{r, byteReader})
+}
+
+type datumReader struct {
+	io.Reader
+	io.ByteReader
+}
+
+type singleByteReader struct {
+	reader io.Reader
+}
+
+func (s *singleByteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(s.reader, b[:])
+	return b[0], err
+}
+
+func readDatum(t *Type, r *datumReader) (interface{}, error) {
+	switch t.kind {
+	case Null:
+		return nil, nil
+	case Boolean:
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return b == 1, nil
+	case Int:
+		v, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("int value out of range: %d", v)
+		}
+		return int32(v), nil
+	case Long:
+		return binary.ReadVarint(r)
+	case Float:
+		var bb [4]byte
+		if _, err := io.ReadFull(r, bb[:]); err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(bb[:])), nil
+	case Double:
+		var bb [8]byte
+		if _, err := io.ReadFull(r, bb[:]); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(bb[:])), nil
+	case Bytes:
+		return readLengthPrefixed(r)
+	case String:
+		bb, err := readLengthPrefixed(r)
+		if err != nil {
+			return nil, err
+		}
+		return string(bb), nil
+	case Record:
+		record := make(map[string]interface{}, len(t.fields))
+		for _, f := range t.fields {
+			v, err := readDatum(f.t, r)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading field %q of record %v: %v", f.name, t.name, err)
+			}
+			record[f.name] = v
+		}
+		return record, nil
+	case Enum:
+		i, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(t.symbols)) {
+			return nil, fmt.Errorf("Invalid index %v for enum %v", i, t.name)
+		}
+		return t.symbols[i], nil
+	case Fixed:
+		bb := make([]byte, t.size)
+		_, err := io.ReadFull(r, bb)
+		return bb, err
+	case Array:
+		return readArray(t, r)
+	case Map:
+		return readMap(t, r)
+	case Union:
+		return readUnion(t, r)
+	}
+	return nil, fmt.Errorf("Unable to read datum of type %v", t.Name())
+}
+
+func readLengthPrefixed(r *datumReader) ([]byte, error) {
+	size, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// As in the generated readString, limit the size so corrupt data can't make us allocate huge buffers
+	if size < 0 || size > math.MaxInt32 {
+		return nil, fmt.Errorf("length out of range: %d", size)
+	}
+
+	bb := make([]byte, size)
+	_, err = io.ReadFull(r, bb)
+	return bb, err
+}
+
+// Arrays and maps are written as a series of blocks, each starting with an item count. A negative count is followed by the size of the block in bytes.
+func readBlockCount(r *datumReader) (int64, error) {
+	count, err := binary.ReadVarint(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if count < 0 {
+		count = -count
+		if _, err = binary.ReadVarint(r); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+func readArray(t *Type, r *datumReader) (interface{}, error) {
+	items := make([]interface{}, 0)
+	for {
+		count, err := readBlockCount(r)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return items, nil
+		}
+
+		for i := int64(0); i < count; i++ {
+			item, err := readDatum(t.items, r)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+	}
+}
+
+func readMap(t *Type, r *datumReader) (interface{}, error) {
+	items := make(map[string]interface{})
+	for {
+		count, err := readBlockCount(r)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return items, nil
+		}
+
+		for i := int64(0); i < count; i++ {
+			key, err := readLengthPrefixed(r)
+			if err != nil {
+				return nil, err
+			}
+
+			item, err := readDatum(t.items, r)
+			if err != nil {
+				return nil, err
+			}
+			items[string(key)] = item
+		}
+	}
+}
+
+func readUnion(t *Type, r *datumReader) (interface{}, error) {
+	i, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if i < 0 || i >= int64(len(t.branches)) {
+		return nil, fmt.Errorf("Invalid union branch %v", i)
+	}
+
+	branch := t.branches[i]
+	if branch.kind == Null {
+		return nil, nil
+	}
+
+	v, err := readDatum(branch, r)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{branch.Name(): v}, nil
+}
@@ -0,0 +1,213 @@
+package schema
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+/*
+  Schema-driven encoding of Avro binary data, the inverse of ReadDatum. Datums are the generic Go values
+  described in datum_reader.go.
+*/
+
+// Write a single datum of the given type to w
+func WriteDatum(t *Type, datum interface{}, w io.Writer) error {
+	return writeDatum(t, datum, &datumWriter{writer: w})
+}
+
+type datumWriter struct {
+	writer io.Writer
+	buf    [binary.MaxVarintLen64]byte
+}
+
+func (w *datumWriter) writeVarint(v int64) error {
+	n := binary.PutVarint(w.buf[:], v)
+	_, err := w.writer.Write(w.buf[:n])
+	return err
+}
+
+func (w *datumWriter) writeLengthPrefixed(bb []byte) error {
+	if err := w.writeVarint(int64(len(bb))); err != nil {
+		return err
+	}
+	_, err := w.writer.Write(bb)
+	return err
+}
+
+func writeDatum(t *Type, datum interface{}, w *datumWriter) error {
+	switch t.kind {
+	case Null:
+		if datum != nil {
+			return wrongDatumType(t, datum)
+		}
+		return nil
+	case Boolean:
+		b, ok := datum.(bool)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		w.buf[0] = 0
+		if b {
+			w.buf[0] = 1
+		}
+		_, err := w.writer.Write(w.buf[:1])
+		return err
+	case Int:
+		i, ok := datum.(int32)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		return w.writeVarint(int64(i))
+	case Long:
+		l, ok := datum.(int64)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		return w.writeVarint(l)
+	case Float:
+		f, ok := datum.(float32)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		binary.LittleEndian.PutUint32(w.buf[:4], math.Float32bits(f))
+		_, err := w.writer.Write(w.buf[:4])
+		return err
+	case Double:
+		f, ok := datum.(float64)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		binary.LittleEndian.PutUint64(w.buf[:8], math.Float64bits(f))
+		_, err := w.writer.Write(w.buf[:8])
+		return err
+	case Bytes:
+		bb, ok := datum.([]byte)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		return w.writeLengthPrefixed(bb)
+	case String:
+		s, ok := datum.(string)
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+		return w.writeLengthPrefixed([]byte(s))
+	case Record:
+		record, ok := datum.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Expected a map[string]interface{} for record %v, got %T", t.name, datum)
+		}
+
+		for _, f := range t.fields {
+			if err := writeDatum(f.t, record[f.name], w); err != nil {
+				return fmt.Errorf("Error writing field %q of record %v: %v", f.name, t.name, err)
+			}
+		}
+		return nil
+	case Enum:
+		symbol, ok := datum.(string)
+		if !ok {
+			return fmt.Errorf("Expected a string for enum %v, got %T", t.name, datum)
+		}
+
+		for i, s := range t.symbols {
+			if s == symbol {
+				return w.writeVarint(int64(i))
+			}
+		}
+		return fmt.Errorf("Invalid symbol %q for enum %v", symbol, t.name)
+	case Fixed:
+		bb, ok := datum.([]byte)
+		if !ok {
+			return fmt.Errorf("Expected a []byte for fixed %v, got %T", t.name, datum)
+		}
+
+		if len(bb) != t.size {
+			return fmt.Errorf("Expected %v bytes for fixed %v, got %v", t.size, t.name, len(bb))
+		}
+		_, err := w.writer.Write(bb)
+		return err
+	case Array:
+		items, ok := datum.([]interface{})
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+
+		// Arrays and maps are written as a single block followed by the empty block which ends them
+		if len(items) > 0 {
+			if err := w.writeVarint(int64(len(items))); err != nil {
+				return err
+			}
+			for _, item := range items {
+				if err := writeDatum(t.items, item, w); err != nil {
+					return err
+				}
+			}
+		}
+		return w.writeVarint(0)
+	case Map:
+		items, ok := datum.(map[string]interface{})
+		if !ok {
+			return wrongDatumType(t, datum)
+		}
+
+		if len(items) > 0 {
+			if err := w.writeVarint(int64(len(items))); err != nil {
+				return err
+			}
+			for k, item := range items {
+				if err := w.writeLengthPrefixed([]byte(k)); err != nil {
+					return err
+				}
+				if err := writeDatum(t.items, item, w); err != nil {
+					return err
+				}
+			}
+		}
+		return w.writeVarint(0)
+	case Union:
+		i, value, err := unionBranch(t, datum)
+		if err != nil {
+			return err
+		}
+
+		if err = w.writeVarint(int64(i)); err != nil {
+			return err
+		}
+		return writeDatum(t.branches[i], value, w)
+	}
+	return fmt.Errorf("Unable to write datum of type %v", t.Name())
+}
+
+// Find the index of the union branch holding a datum, and the datum's value within the branch
+func unionBranch(t *Type, datum interface{}) (int, interface{}, error) {
+	if datum == nil {
+		for i, branch := range t.branches {
+			if branch.kind == Null {
+				return i, nil, nil
+			}
+		}
+		return 0, nil, fmt.Errorf("Union has no null branch")
+	}
+
+	branchValue, ok := datum.(map[string]interface{})
+	if !ok || len(branchValue) != 1 {
+		return 0, nil, fmt.Errorf("Expected a map with a single key for union, got %v", datum)
+	}
+
+	for name, value := range branchValue {
+		for i, branch := range t.branches {
+			if branch.Name() == name {
+				return i, value, nil
+			}
+		}
+		return 0, nil, fmt.Errorf("Union has no branch of type %q", name)
+	}
+	return 0, nil, nil
+}
+
+func wrongDatumType(t *Type, datum interface{}) error {
+	return fmt.Errorf("Unexpected value %v of type %T for %v", datum, datum, t.Name())
+}
@@ -0,0 +1,23 @@
+package schema
+
+import (
+	"fmt"
+)
+
+// ResolutionError is returned when data written with one schema can't be read with another
+type ResolutionError struct {
+	// The path to the type which can't be resolved, starting with the name of the reader's type
+	Path    string
+	Message string
+}
+
+func NewResolutionError(path, message string) *ResolutionError {
+	return &ResolutionError{
+		Path:    path,
+		Message: message,
+	}
+}
+
+func (r *ResolutionError) Error() string {
+	return fmt.Sprintf("Unable to resolve writer schema to reader schema at %v: %v", r.Path, r.Message)
+}
@@ -0,0 +1,512 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+/*
+  Avro schema resolution, for reading data written with one schema (the writer schema) as another (the reader schema).
+
+  Record fields are matched by name or by the reader field's aliases. Fields which are only in the writer schema are
+  skipped, and fields which are only in the reader schema take their default value. Enum symbols are matched by name,
+  falling back to the reader enum's default symbol. Numeric values are promoted from int to long, float or double, from
+  long to float or double and from float to double, and strings and bytes can be read as each other. A value written
+  with a union is resolved using the branch it was written with, and a value read with a union is stored in the first
+  branch with the same type, or failing that the first branch it can be promoted to.
+
+  A writer union can be read as long as one of its branches can be, for example ["null", "int"] can be read as "int".
+  Values written with a branch which can't be read fail when they're resolved. Compatibility checks are stricter,
+  and require every branch to be readable.
+*/
+
+// A Resolver converts datums written with a writer schema to datums of a reader schema
+type Resolver struct {
+	writer *Type
+	reader *Type
+	// The default values of reader fields which are missing from the writer schema
+	defaults map[*Field]interface{}
+}
+
+type resolverKey struct {
+	writerSchema string
+	readerSchema string
+}
+
+var resolverCache sync.Map
+
+// Create a Resolver from a writer schema to a reader schema. Returns a ResolutionError if data written with the writer
+// schema can't always be read with the reader schema.
+func NewResolver(writerSchema, readerSchema string) (*Resolver, error) {
+	writer, err := Parse([]byte(writerSchema))
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing writer schema: %v", err)
+	}
+
+	reader, err := Parse([]byte(readerSchema))
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing reader schema: %v", err)
+	}
+
+	checker := newResolutionChecker(false)
+	checker.check(writer, reader, reader.Name())
+	if len(checker.errs) > 0 {
+		return nil, checker.errs[0]
+	}
+	return &Resolver{writer: writer, reader: reader, defaults: checker.defaults}, nil
+}
+
+// Find or create a Resolver for a pair of schemas. Resolvers are cached, since generated code resolves every record
+// read from a file with the same pair of schemas.
+func CachedResolver(writerSchema, readerSchema string) (*Resolver, error) {
+	key := resolverKey{writerSchema, readerSchema}
+	if resolver, ok := resolverCache.Load(key); ok {
+		return resolver.(*Resolver), nil
+	}
+
+	resolver, err := NewResolver(writerSchema, readerSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	resolverCache.Store(key, resolver)
+	return resolver, nil
+}
+
+// Convert a datum of the writer schema, in the form returned by ReadDatum, to a datum of the reader schema
+func (r *Resolver) ResolveDatum(datum interface{}) (interface{}, error) {
+	return r.resolve(r.writer, r.reader, datum, r.reader.Name())
+}
+
+// Read a datum written with the writer schema from src, and write it to dst in the binary encoding of the reader schema
+func (r *Resolver) Transcode(src io.Reader, dst io.Writer) error {
+	datum, err := ReadDatum(r.writer, src)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := r.ResolveDatum(datum)
+	if err != nil {
+		return err
+	}
+	return WriteDatum(r.reader, resolved, dst)
+}
+
+// Read a datum written with the writer schema from src, and return a reader for the datum in the binary encoding of the reader schema
+func (r *Resolver) TranscodeReader(src io.Reader) (io.Reader, error) {
+	var buf bytes.Buffer
+	if err := r.Transcode(src, &buf); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// Check that data written with the writer type can be read with the reader type, and that the defaults of reader fields
+// which are missing from the writer type are valid. Writer unions only need one branch which can be read.
+// Returns a ResolutionError with the path to the first type which can't be resolved.
+func CheckResolution(writer, reader *Type) error {
+	checker := newResolutionChecker(false)
+	checker.check(writer, reader, reader.Name())
+	if len(checker.errs) > 0 {
+		return checker.errs[0]
+	}
+	return nil
+}
+
+// Find every type in the reader type which can't be resolved from the writer type. Every branch of a writer union must
+// be readable, so every datum written with the writer type can be read.
+func ResolutionErrors(writer, reader *Type) []*ResolutionError {
+	checker := newResolutionChecker(true)
+	checker.check(writer, reader, reader.Name())
+	return checker.errs
+}
+
+// resolutionChecker walks a writer type and a reader type together, collecting the types which can't be resolved
+type resolutionChecker struct {
+	// Whether every branch of a writer union must be readable, rather than at least one
+	strict bool
+	// Whether each pair of named types can be resolved, so recursive types are only checked once
+	resolved map[resolverKey]bool
+	// The default values of reader fields which are missing from the writer type
+	defaults map[*Field]interface{}
+	errs     []*ResolutionError
+}
+
+func newResolutionChecker(strict bool) *resolutionChecker {
+	return &resolutionChecker{
+		strict:   strict,
+		resolved: make(map[resolverKey]bool),
+		defaults: make(map[*Field]interface{}),
+	}
+}
+
+func (c *resolutionChecker) fail(path, message string) {
+	c.errs = append(c.errs, NewResolutionError(path, message))
+}
+
+func (c *resolutionChecker) check(writer, reader *Type, path string) {
+	if writer.kind == Union {
+		c.checkWriterUnion(writer, reader, path)
+		return
+	}
+
+	if reader.kind == Union {
+		i, ok := matchUnionBranch(writer, reader)
+		if !ok {
+			c.fail(path, fmt.Sprintf("Reader union has no branch for writer type %v", writer.Name()))
+			return
+		}
+		c.check(writer, reader.branches[i], path+"<"+reader.branches[i].Name()+">")
+		return
+	}
+
+	if !typesMatch(writer, reader) && !promotable(writer, reader) {
+		c.fail(path, fmt.Sprintf("Writer type %v can't be read as %v", writer.Name(), reader.Name()))
+		return
+	}
+
+	switch reader.kind {
+	case Array:
+		c.check(writer.items, reader.items, path+"[]")
+	case Map:
+		c.check(writer.items, reader.items, path+"{}")
+	case Record, Enum, Fixed:
+		c.checkNamed(writer, reader, path)
+	}
+}
+
+// In strict mode every branch of a writer union is checked, otherwise the union is readable if any branch is
+func (c *resolutionChecker) checkWriterUnion(writer, reader *Type, path string) {
+	if c.strict {
+		for _, writerBranch := range writer.branches {
+			c.check(writerBranch, reader, path)
+		}
+		return
+	}
+
+	var errs []*ResolutionError
+	for _, writerBranch := range writer.branches {
+		branchChecker := &resolutionChecker{resolved: c.resolved, defaults: c.defaults}
+		branchChecker.check(writerBranch, reader, path)
+		if len(branchChecker.errs) == 0 {
+			return
+		}
+		errs = append(errs, branchChecker.errs...)
+	}
+	c.errs = append(c.errs, errs...)
+}
+
+func (c *resolutionChecker) checkNamed(writer, reader *Type, path string) {
+	key := resolverKey{writer.name, reader.name}
+	if resolved, ok := c.resolved[key]; ok {
+		// Types which failed are reported again, so a union branch which uses them isn't treated as readable.
+		// The strict checker reports every error once already.
+		if !resolved && !c.strict {
+			c.fail(path, fmt.Sprintf("Writer type %v can't be read as %v", writer.name, reader.name))
+		}
+		return
+	}
+
+	// Types are assumed to resolve while they're being checked, so recursive types are only checked once
+	c.resolved[key] = true
+	errCount := len(c.errs)
+	defer func() {
+		c.resolved[key] = len(c.errs) == errCount
+	}()
+
+	switch reader.kind {
+	case Record:
+		for _, readerField := range reader.fields {
+			fieldPath := path + "." + readerField.name
+			writerField := matchField(writer, readerField)
+			if writerField != nil {
+				c.check(writerField.t, readerField.t, fieldPath)
+				continue
+			}
+
+			if !readerField.hasDefault {
+				c.fail(fieldPath, "Field is missing from the writer schema and has no default")
+				continue
+			}
+
+			// Defaults are converted once here, rather than for every datum
+			value, err := defaultDatum(readerField.t, readerField.defaultValue)
+			if err != nil {
+				c.fail(fieldPath, fmt.Sprintf("Invalid default value: %v", err))
+				continue
+			}
+			c.defaults[readerField] = value
+		}
+	case Enum:
+		if _, ok := enumDefault(reader); ok {
+			return
+		}
+
+		for _, symbol := range writer.symbols {
+			if !hasSymbol(reader, symbol) {
+				c.fail(path, fmt.Sprintf("Writer symbol %q isn't in the reader enum, which has no default", symbol))
+			}
+		}
+	case Fixed:
+		if writer.size != reader.size {
+			c.fail(path, fmt.Sprintf("Writer fixed has size %v, reader fixed has size %v", writer.size, reader.size))
+		}
+	}
+}
+
+func (r *Resolver) resolve(writer, reader *Type, datum interface{}, path string) (interface{}, error) {
+	if writer.kind == Union {
+		i, value, err := unionBranch(writer, datum)
+		if err != nil {
+			return nil, NewResolutionError(path, err.Error())
+		}
+		return r.resolve(writer.branches[i], reader, value, path)
+	}
+
+	if reader.kind == Union {
+		i, ok := matchUnionBranch(writer, reader)
+		if !ok {
+			return nil, NewResolutionError(path, fmt.Sprintf("Reader union has no branch for writer type %v", writer.Name()))
+		}
+
+		branch := reader.branches[i]
+		value, err := r.resolve(writer, branch, datum, path+"<"+branch.Name()+">")
+		if err != nil {
+			return nil, err
+		}
+
+		if branch.kind == Null {
+			return nil, nil
+		}
+		return map[string]interface{}{branch.Name(): value}, nil
+	}
+
+	if typesMatch(writer, reader) {
+		switch reader.kind {
+		case Record:
+			if record, ok := datum.(map[string]interface{}); ok {
+				return r.resolveRecord(writer, reader, record, path)
+			}
+		case Enum:
+			if symbol, ok := datum.(string); ok {
+				return resolveSymbol(reader, symbol, path)
+			}
+		case Array:
+			items, ok := datum.([]interface{})
+			if !ok {
+				break
+			}
+
+			resolved := make([]interface{}, len(items))
+			for i, item := range items {
+				var err error
+				if resolved[i], err = r.resolve(writer.items, reader.items, item, path+"[]"); err != nil {
+					return nil, err
+				}
+			}
+			return resolved, nil
+		case Map:
+			items, ok := datum.(map[string]interface{})
+			if !ok {
+				break
+			}
+
+			resolved := make(map[string]interface{}, len(items))
+			for k, item := range items {
+				var err error
+				if resolved[k], err = r.resolve(writer.items, reader.items, item, path+"{}"); err != nil {
+					return nil, err
+				}
+			}
+			return resolved, nil
+		default:
+			return datum, nil
+		}
+	} else if promoted, ok := promote(datum, reader); ok {
+		return promoted, nil
+	}
+	return nil, NewResolutionError(path, fmt.Sprintf("Writer type %v can't be read as %v", writer.Name(), reader.Name()))
+}
+
+func (r *Resolver) resolveRecord(writer, reader *Type, record map[string]interface{}, path string) (interface{}, error) {
+	resolved := make(map[string]interface{}, len(reader.fields))
+	for _, readerField := range reader.fields {
+		fieldPath := path + "." + readerField.name
+		writerField := matchField(writer, readerField)
+		if writerField == nil {
+			// Records in a writer union branch which can't be read may have fields without a valid default
+			value, ok := r.defaults[readerField]
+			if !ok {
+				return nil, NewResolutionError(fieldPath, "Field is missing from the writer schema and has no valid default")
+			}
+			resolved[readerField.name] = copyDatum(value)
+			continue
+		}
+
+		value, err := r.resolve(writerField.t, readerField.t, record[writerField.name], fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		resolved[readerField.name] = value
+	}
+	return resolved, nil
+}
+
+func resolveSymbol(reader *Type, symbol, path string) (interface{}, error) {
+	if hasSymbol(reader, symbol) {
+		return symbol, nil
+	}
+	if def, ok := enumDefault(reader); ok {
+		return def, nil
+	}
+	return nil, NewResolutionError(path, fmt.Sprintf("Writer symbol %q isn't in the reader enum, which has no default", symbol))
+}
+
+// Copy the maps and slices in a datum, so resolved datums don't share a field's default value
+func copyDatum(datum interface{}) interface{} {
+	switch v := datum.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			copied[k] = copyDatum(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyDatum(item)
+		}
+		return copied
+	case []byte:
+		return append([]byte{}, v...)
+	}
+	return datum
+}
+
+// Find the first branch of a reader union with the same type as the writer type, or failing that the first branch it can be promoted to
+func matchUnionBranch(writer, reader *Type) (int, bool) {
+	for i, branch := range reader.branches {
+		if typesMatch(writer, branch) {
+			return i, true
+		}
+	}
+
+	for i, branch := range reader.branches {
+		if promotable(writer, branch) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Whether two types are the same kind of type, and have matching names if they're named types.
+// Array items and map values aren't compared.
+func typesMatch(writer, reader *Type) bool {
+	if writer.kind != reader.kind || writer.kind == Union {
+		return false
+	}
+
+	switch writer.kind {
+	case Record, Enum, Fixed:
+		return namesMatch(writer.name, reader)
+	}
+	return true
+}
+
+// Named types match if they have the same unqualified name, or the writer's name is one of the reader's aliases
+func namesMatch(writerName string, reader *Type) bool {
+	name := shortName(writerName)
+	if name == shortName(reader.name) {
+		return true
+	}
+
+	for _, alias := range reader.aliases {
+		if name == shortName(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether values of the writer type can be promoted to the reader type
+func promotable(writer, reader *Type) bool {
+	switch writer.kind {
+	case Int:
+		return reader.kind == Long || reader.kind == Float || reader.kind == Double
+	case Long:
+		return reader.kind == Float || reader.kind == Double
+	case Float:
+		return reader.kind == Double
+	case String:
+		return reader.kind == Bytes
+	case Bytes:
+		return reader.kind == String
+	}
+	return false
+}
+
+func promote(datum interface{}, reader *Type) (interface{}, bool) {
+	switch v := datum.(type) {
+	case int32:
+		switch reader.kind {
+		case Long:
+			return int64(v), true
+		case Float:
+			return float32(v), true
+		case Double:
+			return float64(v), true
+		}
+	case int64:
+		switch reader.kind {
+		case Float:
+			return float32(v), true
+		case Double:
+			return float64(v), true
+		}
+	case float32:
+		if reader.kind == Double {
+			return float64(v), true
+		}
+	case string:
+		if reader.kind == Bytes {
+			return []byte(v), true
+		}
+	case []byte:
+		if reader.kind == String {
+			return string(v), true
+		}
+	}
+	return nil, false
+}
+
+// Find the writer field for a reader field, by the reader field's name or aliases
+func matchField(writer *Type, readerField *Field) *Field {
+	names := append([]string{readerField.name}, readerField.aliases...)
+	for _, name := range names {
+		for _, writerField := range writer.fields {
+			if writerField.name == name {
+				return writerField
+			}
+		}
+	}
+	return nil
+}
+
+func hasSymbol(e *Type, symbol string) bool {
+	for _, s := range e.symbols {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// The symbol used for writer symbols which aren't in the reader enum, if the enum has a default
+func enumDefault(e *Type) (string, bool) {
+	if e.enumDefault == "" || !hasSymbol(e, e.enumDefault) {
+		return "", false
+	}
+	return e.enumDefault, true
+}
@@ -0,0 +1,246 @@
+// Package schema reads, writes, resolves and fingerprints Avro data with schemas known at runtime. Schemas are parsed
+// by the types package, like the code generator's, so both agree on names, aliases and defaults.
+// It's used by generated code and the container, soe and serde packages.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/actgardner/gogen-avro/types"
+)
+
+// Kind identifies one of the Avro types
+type Kind int
+
+const (
+	Null Kind = iota
+	Boolean
+	Int
+	Long
+	Float
+	Double
+	Bytes
+	String
+	Record
+	Enum
+	Array
+	Map
+	Union
+	Fixed
+)
+
+var kindNames = map[Kind]string{
+	Null:    "null",
+	Boolean: "boolean",
+	Int:     "int",
+	Long:    "long",
+	Float:   "float",
+	Double:  "double",
+	Bytes:   "bytes",
+	String:  "string",
+	Record:  "record",
+	Enum:    "enum",
+	Array:   "array",
+	Map:     "map",
+	Union:   "union",
+	Fixed:   "fixed",
+}
+
+var primitiveKinds = map[string]Kind{
+	"null":    Null,
+	"boolean": Boolean,
+	"int":     Int,
+	"long":    Long,
+	"float":   Float,
+	"double":  Double,
+	"bytes":   Bytes,
+	"string":  String,
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Type is a parsed Avro type. Named types are shared by every reference to them, so the types of recursive records form a cycle.
+type Type struct {
+	kind Kind
+	// The generator's type this was built from, which the canonical form is written from
+	avroType types.AvroType
+	// The full name and the full names of the aliases of records, enums and fixed types
+	name    string
+	aliases []string
+	// The item type of arrays and the value type of maps
+	items *Type
+	// The branches of unions
+	branches []*Type
+	// The fields of records
+	fields []*Field
+	// The symbols of enums, and the symbol used for writer symbols which aren't in the enum, if there is one
+	symbols     []string
+	enumDefault string
+	// The size of fixed types in bytes
+	size int
+}
+
+// Field is a field of a record type
+type Field struct {
+	name    string
+	aliases []string
+	t       *Type
+	// The default value as it appears in the schema, with numbers as json.Number
+	defaultValue interface{}
+	hasDefault   bool
+}
+
+// The kind of type
+func (t *Type) Kind() Kind {
+	return t.kind
+}
+
+// The name used to identify the branch of a union holding this type: the full name of records, enums and fixed types,
+// and the type's kind otherwise
+func (t *Type) Name() string {
+	switch t.kind {
+	case Record, Enum, Fixed:
+		return t.name
+	}
+	return t.kind.String()
+}
+
+// The branches of a union type, or nil for other types
+func (t *Type) Branches() []*Type {
+	return t.branches
+}
+
+// Parse a schema with the same rules as the code generator, using types.ParseSchema. A named type can be defined more
+// than once, as generated schemas sometimes do, as long as every definition is the same.
+func Parse(schemaJson []byte) (*Type, error) {
+	avroType, err := types.ParseSchema(schemaJson)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &converter{named: make(map[types.Definition]*Type)}
+	return c.convert(avroType)
+}
+
+// converter builds Types from the generator's types, sharing one Type between every reference to a named type
+type converter struct {
+	named map[types.Definition]*Type
+}
+
+func (c *converter) convert(avroType types.AvroType) (*Type, error) {
+	if reference, ok := avroType.(*types.Reference); ok {
+		return c.convertDefinition(reference)
+	}
+
+	if branches, ok := types.UnionItemTypes(avroType); ok {
+		union := &Type{kind: Union, avroType: avroType}
+		for _, branch := range branches {
+			branchType, err := c.convert(branch)
+			if err != nil {
+				return nil, err
+			}
+			union.branches = append(union.branches, branchType)
+		}
+		return union, nil
+	}
+
+	if items, ok := types.ArrayItemType(avroType); ok {
+		itemType, err := c.convert(items)
+		if err != nil {
+			return nil, err
+		}
+		return &Type{kind: Array, avroType: avroType, items: itemType}, nil
+	}
+
+	if values, ok := types.MapValueType(avroType); ok {
+		valueType, err := c.convert(values)
+		if err != nil {
+			return nil, err
+		}
+		return &Type{kind: Map, avroType: avroType, items: valueType}, nil
+	}
+
+	name := types.AvroTypeName(avroType)
+	if kind, ok := primitiveKinds[name]; ok {
+		return &Type{kind: kind, avroType: avroType}, nil
+	}
+	return nil, fmt.Errorf("Unsupported type %v", name)
+}
+
+// Convert the record, enum or fixed definition a reference refers to. Records are registered before their fields are
+// converted, so records can refer to themselves.
+func (c *converter) convertDefinition(reference *types.Reference) (*Type, error) {
+	definition := reference.ResolvedDefinition()
+	if t, ok := c.named[definition]; ok {
+		return t, nil
+	}
+
+	t := &Type{avroType: reference, name: definition.AvroName().String()}
+	for _, alias := range definition.Aliases() {
+		t.aliases = append(t.aliases, alias.String())
+	}
+	c.named[definition] = t
+
+	switch d := definition.(type) {
+	case *types.RecordDefinition:
+		t.kind = Record
+		for _, f := range d.Fields() {
+			fieldType, err := c.convert(f.Type())
+			if err != nil {
+				return nil, fmt.Errorf("Error converting field %q of record %v: %v", f.Name(), t.name, err)
+			}
+
+			t.fields = append(t.fields, &Field{
+				name:         f.Name(),
+				aliases:      f.Aliases(),
+				t:            fieldType,
+				defaultValue: jsonNumbers(f.Default()),
+				hasDefault:   f.HasDefault(),
+			})
+		}
+	case *types.EnumDefinition:
+		t.kind = Enum
+		t.symbols = d.Symbols()
+		t.enumDefault = d.Default()
+	case *types.FixedDefinition:
+		t.kind = Fixed
+		t.size = d.SizeBytes()
+	default:
+		return nil, fmt.Errorf("Unsupported definition %v", t.name)
+	}
+	return t, nil
+}
+
+// Replace the float64 numbers in a decoded JSON value with json.Number, the way defaults are read from JSON data
+func jsonNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		n, _ := json.Marshal(v)
+		return json.Number(n)
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = jsonNumbers(item)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			converted[k] = jsonNumbers(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// The last part of a full name
+func shortName(fullName string) string {
+	return fullName[strings.LastIndex(fullName, ".")+1:]
+}
@@ -0,0 +1,24 @@
+package serde
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidMagicByte is returned when a message doesn't start with the Confluent wire format's magic byte
+var ErrInvalidMagicByte = errors.New("Invalid magic byte, not a schema registry framed Avro message")
+
+// UnknownSchemaIDError is returned when a registry has no schema with the requested ID
+type UnknownSchemaIDError struct {
+	ID int32
+}
+
+func NewUnknownSchemaIDError(id int32) *UnknownSchemaIDError {
+	return &UnknownSchemaIDError{
+		ID: id,
+	}
+}
+
+func (u *UnknownSchemaIDError) Error() string {
+	return fmt.Sprintf("No schema with ID %v in the schema registry", u.ID)
+}
@@ -0,0 +1,211 @@
+package serde
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/actgardner/gogen-avro/schema"
+)
+
+// SchemaRegistry assigns IDs to schemas and finds schemas by ID, like the Confluent Schema Registry.
+// Implementations backed by a real registry can be plugged into a Serializer and Deserializer; the implementations in
+// this package keep the schemas in memory or in a local file, for tests and tools.
+type SchemaRegistry interface {
+	// Register a schema under a subject and return its ID. Registering a schema which is already registered returns the existing ID.
+	Register(subject, schema string) (int32, error)
+	// Find the schema with the given ID. Returns an UnknownSchemaIDError if there is no schema with that ID.
+	Schema(id int32) (string, error)
+}
+
+// MemoryRegistry is a SchemaRegistry which keeps schemas in memory. Schemas with the same Parsing Canonical Form
+// share an ID, whichever subject they're registered under. It is safe for concurrent use.
+type MemoryRegistry struct {
+	lock sync.RWMutex
+	// The schemas by ID, and the IDs of the schemas registered under each subject in the order they were registered
+	schemas  map[int32]string
+	subjects map[string][]int32
+	// The ID of each schema by canonical form
+	ids map[string]int32
+}
+
+// registryFile is the contents of a FileRegistry's file
+type registryFile struct {
+	Schemas  map[int32]string   `json:"schemas"`
+	Subjects map[string][]int32 `json:"subjects"`
+}
+
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		schemas:  make(map[int32]string),
+		subjects: make(map[string][]int32),
+		ids:      make(map[string]int32),
+	}
+}
+
+func (m *MemoryRegistry) Register(subject, schema string) (int32, error) {
+	canonical, err := canonicalForm(schema)
+	if err != nil {
+		return 0, err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	id, _ := m.register(subject, schema, canonical)
+	return id, nil
+}
+
+// Register a schema while holding the lock. Returns the schema's ID, and whether it was added to the subject.
+func (m *MemoryRegistry) register(subject, schema, canonical string) (int32, bool) {
+	id, ok := m.ids[canonical]
+	if !ok {
+		id = int32(len(m.schemas) + 1)
+		m.schemas[id] = schema
+		m.ids[canonical] = id
+	}
+
+	for _, subjectID := range m.subjects[subject] {
+		if subjectID == id {
+			return id, false
+		}
+	}
+	m.subjects[subject] = append(m.subjects[subject], id)
+	return id, true
+}
+
+func (m *MemoryRegistry) Schema(id int32) (string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	schema, ok := m.schemas[id]
+	if !ok {
+		return "", NewUnknownSchemaIDError(id)
+	}
+	return schema, nil
+}
+
+// The subjects which have schemas registered under them, in alphabetical order
+func (m *MemoryRegistry) Subjects() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	subjects := make([]string, 0, len(m.subjects))
+	for subject := range m.subjects {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	return subjects
+}
+
+// The IDs of the schemas registered under a subject, in the order they were registered
+func (m *MemoryRegistry) SubjectIDs(subject string) []int32 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return append([]int32(nil), m.subjects[subject]...)
+}
+
+// Replace the registry's schemas with the contents of a registry file, and rebuild the index of IDs by canonical form
+func (m *MemoryRegistry) load(contents registryFile) error {
+	ids := make(map[string]int32)
+	for id, schema := range contents.Schemas {
+		canonical, err := canonicalForm(schema)
+		if err != nil {
+			return err
+		}
+		ids[canonical] = id
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if contents.Schemas != nil {
+		m.schemas = contents.Schemas
+	}
+	if contents.Subjects != nil {
+		m.subjects = contents.Subjects
+	}
+	m.ids = ids
+	return nil
+}
+
+// FileRegistry is a MemoryRegistry which saves its schemas to a JSON file after each new registration,
+// so IDs are kept between runs of a test or tool
+type FileRegistry struct {
+	*MemoryRegistry
+	path string
+}
+
+// Create a FileRegistry which stores schemas in the file at path, loading any schemas already in the file
+func NewFileRegistry(path string) (*FileRegistry, error) {
+	registry := &FileRegistry{
+		MemoryRegistry: NewMemoryRegistry(),
+		path:           path,
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file registryFile
+	if err = json.Unmarshal(contents, &file); err != nil {
+		return nil, err
+	}
+
+	if err = registry.load(file); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Register a schema, and save the registry if the schema wasn't already registered under the subject.
+// The lock is held until the file has been saved, so concurrent registrations are saved in order.
+func (f *FileRegistry) Register(subject, schema string) (int32, error) {
+	canonical, err := canonicalForm(schema)
+	if err != nil {
+		return 0, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	id, added := f.register(subject, schema, canonical)
+	if !added {
+		return id, nil
+	}
+	return id, f.save()
+}
+
+// Write the registry to a temporary file and rename it over the registry file, so the file is never left half written.
+// The lock must be held by the caller.
+func (f *FileRegistry) save() error {
+	contents, err := json.MarshalIndent(registryFile{Schemas: f.schemas, Subjects: f.subjects}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(contents)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func canonicalForm(schemaJson string) (string, error) {
+	avroType, err := schema.Parse([]byte(schemaJson))
+	if err != nil {
+		return "", err
+	}
+	return schema.CanonicalForm(avroType)
+}
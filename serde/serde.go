@@ -0,0 +1,173 @@
+// Package serde implements the Confluent Schema Registry wire format, which prefixes a datum with a magic byte
+// and the registry ID of the schema it was written with, as used by Kafka serializers and deserializers.
+package serde
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/actgardner/gogen-avro/container"
+)
+
+// The byte at the start of every message in the wire format
+const MagicByte byte = 0
+
+// The length of the magic byte and schema ID which precede the datum
+const HeaderSize = 5
+
+// Write the magic byte and the big-endian schema ID of the datum's schema
+func WriteHeader(w io.Writer, id int32) error {
+	var header [HeaderSize]byte
+	header[0] = MagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(id))
+	_, err := w.Write(header[:])
+	return err
+}
+
+// Read the magic byte and return the ID of the schema the datum was written with. The datum follows in r.
+// Returns ErrInvalidMagicByte if r doesn't start with the magic byte.
+func ReadHeader(r io.Reader) (int32, error) {
+	var header [HeaderSize]byte
+	_, err := io.ReadFull(r, header[:])
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return 0, ErrInvalidMagicByte
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if header[0] != MagicByte {
+		return 0, ErrInvalidMagicByte
+	}
+	return int32(binary.BigEndian.Uint32(header[1:])), nil
+}
+
+// Serializer writes generated records in the wire format, registering each record's schema the first time it's written to a subject
+type Serializer struct {
+	registry SchemaRegistry
+	strategy SubjectNameStrategy
+	isKey    bool
+	// The subjects chosen by the strategy, keyed by topic and schema, since strategies may have to parse the schema
+	subjects sync.Map
+	// The registered schema IDs, keyed by subject and schema
+	ids sync.Map
+}
+
+type topicSchema struct {
+	topic  string
+	schema string
+}
+
+type subjectSchema struct {
+	subject string
+	schema  string
+}
+
+// Create a Serializer for message keys if isKey is true, or message values otherwise.
+// If strategy is nil the TopicNameStrategy is used.
+func NewSerializer(registry SchemaRegistry, strategy SubjectNameStrategy, isKey bool) *Serializer {
+	if strategy == nil {
+		strategy = TopicNameStrategy
+	}
+	return &Serializer{
+		registry: registry,
+		strategy: strategy,
+		isKey:    isKey,
+	}
+}
+
+// Serialize a record to be written to a topic
+func (s *Serializer) Serialize(topic string, record container.AvroRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := s.Write(&buf, topic, record); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write a record to be written to a topic to w
+func (s *Serializer) Write(w io.Writer, topic string, record container.AvroRecord) error {
+	recordSchema := record.Schema()
+	subject, err := s.subject(topic, recordSchema, record)
+	if err != nil {
+		return err
+	}
+
+	key := subjectSchema{subject, recordSchema}
+	id, ok := s.ids.Load(key)
+	if !ok {
+		registered, err := s.registry.Register(subject, key.schema)
+		if err != nil {
+			return err
+		}
+		s.ids.Store(key, registered)
+		id = registered
+	}
+
+	if err = WriteHeader(w, id.(int32)); err != nil {
+		return err
+	}
+	return record.Serialize(w)
+}
+
+// Find the subject for a record's schema in a topic. Each serializer is only for keys or only for values,
+// so the subject only depends on the topic and the schema.
+func (s *Serializer) subject(topic, recordSchema string, record container.AvroRecord) (string, error) {
+	key := topicSchema{topic, recordSchema}
+	if subject, ok := s.subjects.Load(key); ok {
+		return subject.(string), nil
+	}
+
+	subject, err := s.strategy(topic, s.isKey, record)
+	if err != nil {
+		return "", err
+	}
+	s.subjects.Store(key, subject)
+	return subject, nil
+}
+
+// Deserializer reads the header of messages in the wire format and finds the schema they were written with.
+// The datum can then be read with the generated Deserialize<Record>FromSchema function, which resolves the writer's schema
+// to the generated record's schema.
+type Deserializer struct {
+	registry SchemaRegistry
+	// The schemas which have been looked up, keyed by ID
+	schemas sync.Map
+}
+
+func NewDeserializer(registry SchemaRegistry) *Deserializer {
+	return &Deserializer{
+		registry: registry,
+	}
+}
+
+// Read the header of a message and return the schema the datum was written with, and a reader for the datum
+func (d *Deserializer) Deserialize(data []byte) (string, io.Reader, error) {
+	r := bytes.NewReader(data)
+	schema, err := d.Read(r)
+	if err != nil {
+		return "", nil, err
+	}
+	return schema, r, nil
+}
+
+// Read the header of a message from r and return the schema the datum was written with. The datum follows in r.
+func (d *Deserializer) Read(r io.Reader) (string, error) {
+	id, err := ReadHeader(r)
+	if err != nil {
+		return "", err
+	}
+
+	if schema, ok := d.schemas.Load(id); ok {
+		return schema.(string), nil
+	}
+
+	schema, err := d.registry.Schema(id)
+	if err != nil {
+		return "", err
+	}
+	d.schemas.Store(id, schema)
+	return schema, nil
+}
@@ -0,0 +1,51 @@
+package serde
+
+import (
+	"fmt"
+
+	"github.com/actgardner/gogen-avro/container"
+	"github.com/actgardner/gogen-avro/schema"
+)
+
+// SubjectNameStrategy picks the registry subject a record's schema is registered under when it's written to a topic,
+// as a message key if isKey is true or as a message value otherwise. A Serializer caches the subject for each topic and schema,
+// so the subject must only depend on the topic, isKey and the record's schema.
+type SubjectNameStrategy func(topic string, isKey bool, record container.AvroRecord) (string, error)
+
+// TopicNameStrategy uses <topic>-key or <topic>-value as the subject, so every message value in a topic has versions of one schema.
+// This is the default strategy of the Confluent serializers.
+func TopicNameStrategy(topic string, isKey bool, record container.AvroRecord) (string, error) {
+	if isKey {
+		return topic + "-key", nil
+	}
+	return topic + "-value", nil
+}
+
+// RecordNameStrategy uses the record's full name as the subject, so a topic can hold several record types
+// and each type has the same subject in every topic
+func RecordNameStrategy(topic string, isKey bool, record container.AvroRecord) (string, error) {
+	return recordName(record)
+}
+
+// TopicRecordNameStrategy uses <topic>-<record full name> as the subject, so a topic can hold several record types
+// and each type has a separate subject in each topic
+func TopicRecordNameStrategy(topic string, isKey bool, record container.AvroRecord) (string, error) {
+	name, err := recordName(record)
+	if err != nil {
+		return "", err
+	}
+	return topic + "-" + name, nil
+}
+
+func recordName(record container.AvroRecord) (string, error) {
+	avroType, err := schema.Parse([]byte(record.Schema()))
+	if err != nil {
+		return "", err
+	}
+
+	switch avroType.Kind() {
+	case schema.Record, schema.Enum, schema.Fixed:
+		return avroType.Name(), nil
+	}
+	return "", fmt.Errorf("Unable to name subject for schema of type %v, expected a named type", avroType.Name())
+}
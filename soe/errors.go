@@ -0,0 +1,24 @@
+package soe
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidMarker is returned when a message does not start with the single-object encoding marker
+var ErrInvalidMarker = errors.New("Invalid marker, not an Avro single-object encoded message")
+
+// UnknownFingerprintError is returned when a message was written with a schema which isn't in the SchemaStore
+type UnknownFingerprintError struct {
+	Fingerprint uint64
+}
+
+func NewUnknownFingerprintError(fingerprint uint64) *UnknownFingerprintError {
+	return &UnknownFingerprintError{
+		Fingerprint: fingerprint,
+	}
+}
+
+func (u *UnknownFingerprintError) Error() string {
+	return fmt.Sprintf("Unknown schema fingerprint %#016x, the message's schema must be added to the SchemaStore", u.Fingerprint)
+}
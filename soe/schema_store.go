@@ -0,0 +1,55 @@
+package soe
+
+import (
+	"sync"
+
+	"github.com/actgardner/gogen-avro/schema"
+)
+
+// SchemaStore maps fingerprints to the schemas messages may have been written with, so messages written with older
+// or newer versions of a schema can be resolved to the reader's schema. It is safe for concurrent use.
+type SchemaStore struct {
+	lock    sync.RWMutex
+	schemas map[uint64]string
+}
+
+func NewSchemaStore() *SchemaStore {
+	return &SchemaStore{
+		schemas: make(map[uint64]string),
+	}
+}
+
+// Parse a schema and add it to the store, returning its fingerprint
+func (s *SchemaStore) Add(schemaJson string) (uint64, error) {
+	avroType, err := schema.Parse([]byte(schemaJson))
+	if err != nil {
+		return 0, err
+	}
+
+	canonical, err := schema.CanonicalForm(avroType)
+	if err != nil {
+		return 0, err
+	}
+
+	fingerprint := schema.Fingerprint64([]byte(canonical))
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.schemas[fingerprint] = schemaJson
+	return fingerprint, nil
+}
+
+// Find the schema with the given fingerprint. Returns an UnknownFingerprintError if the schema hasn't been added,
+// or if the store is nil.
+func (s *SchemaStore) Lookup(fingerprint uint64) (string, error) {
+	if s == nil {
+		return "", NewUnknownFingerprintError(fingerprint)
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	schema, ok := s.schemas[fingerprint]
+	if !ok {
+		return "", NewUnknownFingerprintError(fingerprint)
+	}
+	return schema, nil
+}
@@ -0,0 +1,41 @@
+// Package soe implements the Avro single-object encoding, which prefixes a single datum with the fingerprint of the
+// schema it was written with so it can be stored or sent on its own, for example as a message or a cache entry.
+package soe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// The two bytes at the start of every single-object encoded message
+var Marker = [2]byte{0xc3, 0x01}
+
+// The length of the marker and fingerprint which precede the datum
+const HeaderSize = 10
+
+// Write the marker and the little-endian CRC-64-AVRO fingerprint of the datum's schema
+func WriteHeader(w io.Writer, fingerprint uint64) error {
+	var header [HeaderSize]byte
+	copy(header[:], Marker[:])
+	binary.LittleEndian.PutUint64(header[2:], fingerprint)
+	_, err := w.Write(header[:])
+	return err
+}
+
+// Read the marker and return the fingerprint of the schema the datum was written with. The datum follows in r.
+// Returns ErrInvalidMarker if r doesn't start with the marker.
+func ReadHeader(r io.Reader) (uint64, error) {
+	var header [HeaderSize]byte
+	_, err := io.ReadFull(r, header[:])
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return 0, ErrInvalidMarker
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if header[0] != Marker[0] || header[1] != Marker[1] {
+		return 0, ErrInvalidMarker
+	}
+	return binary.LittleEndian.Uint64(header[2:]), nil
+}
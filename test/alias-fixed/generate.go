@@ -1,3 +1,3 @@
 package avro
 
-//go:generate $GOPATH/bin/gogen-avro --containers . union.avsc
+//go:generate $GOPATH/bin/gogen-avro --containers --single-object . union.avsc
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/actgardner/gogen-avro/serde"
+	"github.com/actgardner/gogen-avro/soe"
 	"github.com/linkedin/goavro"
 	"github.com/stretchr/testify/assert"
 )
@@ -74,3 +76,45 @@ func TestRoundTrip(t *testing.T) {
 		assert.Equal(t, datum, &f)
 	}
 }
+
+// The generated schema defines ip_address and its aliases once for each field which uses it
+func TestSerdeRecordName(t *testing.T) {
+	record := &fixtures[0]
+	registry := serde.NewMemoryRegistry()
+	id, err := registry.Register("events-value", record.Schema())
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), id)
+
+	serializer := serde.NewSerializer(registry, serde.RecordNameStrategy, false)
+	data, err := serializer.Serialize("events", record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int32{1}, registry.SubjectIDs("event"))
+
+	schema, r, err := serde.NewDeserializer(registry).Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DeserializeEventFromSchema(r, schema)
+	assert.Nil(t, err)
+	assert.Equal(t, record, decoded)
+}
+
+func TestSingleObjectStore(t *testing.T) {
+	record := &fixtures[1]
+	store := soe.NewSchemaStore()
+	fingerprint, err := store.Add(record.Schema())
+	assert.Nil(t, err)
+	assert.Equal(t, record.Fingerprint64(), fingerprint)
+
+	data, err := record.MarshalSingleObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := UnmarshalEventSingleObject(data, store)
+	assert.Nil(t, err)
+	assert.Equal(t, record, decoded)
+}
@@ -0,0 +1,82 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/actgardner/gogen-avro/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+var fixtureAvroJSON = []string{
+	`{"UnionField":null}`,
+	`{"UnionField":{"array":[1,2,3]}}`,
+	`{"UnionField":{"map":{"a":1,"b":3,"c":5}}}`,
+	`{"UnionField":{"NestedUnionRecord":{"IntField":789}}}`,
+}
+
+func TestDatumToJSON(t *testing.T) {
+	fixtures := make([]ComplexUnionTestRecord, 0)
+	err := json.Unmarshal([]byte(fixtureJson), &fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	avroType, err := schema.Parse([]byte(fixtures[0].Schema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, f := range fixtures {
+		var buf bytes.Buffer
+		if err = f.Serialize(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		datum, err := schema.ReadDatum(avroType, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encoded, err := schema.DatumToJSON(avroType, datum)
+		assert.Nil(t, err)
+		assert.Equal(t, fixtureAvroJSON[i], string(encoded))
+	}
+}
+
+func TestDatumFromJSON(t *testing.T) {
+	fixtures := make([]ComplexUnionTestRecord, 0)
+	err := json.Unmarshal([]byte(fixtureJson), &fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	avroType, err := schema.Parse([]byte(fixtures[0].Schema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, line := range fixtureAvroJSON {
+		datum, err := schema.DatumFromJSON(avroType, []byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err = schema.WriteDatum(avroType, datum, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := DeserializeComplexUnionTestRecord(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, fixtures[i], *record)
+	}
+
+	_, err = schema.DatumFromJSON(avroType, []byte(`{"UnionField":{"string":"abc"}}`))
+	assert.NotNil(t, err)
+	_, err = schema.DatumFromJSON(avroType, []byte(`{"UnionField":[1,2,3]}`))
+	assert.NotNil(t, err)
+}
@@ -3,10 +3,18 @@ package avro
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/actgardner/gogen-avro/container"
+	"github.com/actgardner/gogen-avro/schema"
 	"github.com/linkedin/goavro"
+	"github.com/stretchr/testify/assert"
 )
 
 // Round-trip some primitive values through our container file writer and goavro to verify
@@ -67,3 +75,570 @@ func roundTripWithCodec(codec container.Codec, t *testing.T) {
 		i = i + 1
 	}
 }
+
+// The records in fixtureJson
+func loadFixtures(t *testing.T) []PrimitiveTestRecord {
+	fixtures := make([]PrimitiveTestRecord, 0)
+	err := json.Unmarshal([]byte(fixtureJson), &fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fixtures
+}
+
+// Records with IntField set to start, start+1, ...
+func rangeRecords(start, count int) []PrimitiveTestRecord {
+	records := make([]PrimitiveTestRecord, count)
+	for i := range records {
+		records[i] = *NewPrimitiveTestRecord()
+		records[i].IntField = int32(start + i)
+		// Empty bytes are deserialized as an empty slice rather than nil
+		records[i].BytesField = []byte{}
+	}
+	return records
+}
+
+func writeRecords(containerWriter *container.Writer, records []PrimitiveTestRecord, t *testing.T) {
+	for i := range records {
+		if err := containerWriter.WriteRecord(&records[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Write records to a new container file with the given codec and block size, and close it
+func writeContainer(records []PrimitiveTestRecord, codec container.Codec, recordsPerBlock int64, t *testing.T, opts ...container.WriterOption) []byte {
+	var buf bytes.Buffer
+	containerWriter, err := NewPrimitiveTestRecordWriter(&buf, codec, recordsPerBlock, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeRecords(containerWriter, records, t)
+	if err = containerWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Read every record with the generated reader, and check they match the expected records
+func checkGeneratedReader(r io.Reader, expected []PrimitiveTestRecord, t *testing.T) {
+	reader, err := NewPrimitiveTestRecordReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var i int
+	for reader.Next() {
+		assert.Equal(t, expected[i], *reader.Record())
+		i = i + 1
+	}
+	assert.Nil(t, reader.Err())
+	assert.Equal(t, len(expected), i)
+}
+
+func TestGeneratedReader(t *testing.T) {
+	fixtures := loadFixtures(t)
+	checkGeneratedReader(bytes.NewReader(writeContainer(fixtures, container.Deflate, 2, t)), fixtures, t)
+}
+
+func TestGeneratedReaderSchemaMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	containerWriter, err := container.NewWriter(&buf, container.Null, 2, `{"type": "record", "name": "Other", "fields": [{"name": "IntField", "type": "int"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = containerWriter.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewPrimitiveTestRecordReader(&buf)
+	_, ok := err.(*container.SchemaMismatchError)
+	assert.True(t, ok)
+}
+
+func TestAppendWriter(t *testing.T) {
+	fixtures := loadFixtures(t)
+
+	file, err := ioutil.TempFile("", "append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	containerWriter, err := NewPrimitiveTestRecordWriter(file, container.Snappy, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlock(file, containerWriter, fixtures[:1], t)
+
+	// Reopen the file and append the rest of the fixtures
+	appendWriter, err := NewPrimitiveTestRecordAppendWriter(file, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlock(file, appendWriter, fixtures[1:], t)
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGeneratedReader(file, fixtures, t)
+}
+
+func TestAppendWriterSchemaMismatch(t *testing.T) {
+	file, err := ioutil.TempFile("", "append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	_, err = container.NewWriter(file, container.Null, 2, `{"type": "record", "name": "Other", "fields": [{"name": "IntField", "type": "int"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewPrimitiveTestRecordAppendWriter(file, 2)
+	_, ok := err.(*container.SchemaMismatchError)
+	assert.True(t, ok)
+}
+
+// Write records to a file and flush them as one block, returning the size of the file
+func appendBlock(file *os.File, writer *container.Writer, records []PrimitiveTestRecord, t *testing.T) int64 {
+	writeRecords(writer, records, t)
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return size
+}
+
+func TestAppendWriterIncompleteBlock(t *testing.T) {
+	file, err := ioutil.TempFile("", "append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	containerWriter, err := NewPrimitiveTestRecordWriter(file, container.Deflate, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	complete := appendBlock(file, containerWriter, rangeRecords(0, 3), t)
+	torn := appendBlock(file, containerWriter, rangeRecords(100, 3), t)
+
+	// Cut the second block short, as if the writer crashed while writing it
+	if err = file.Truncate(torn - 5); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a Truncate method the partial block can't be removed
+	_, err = NewPrimitiveTestRecordAppendWriter(struct{ io.ReadWriteSeeker }{file}, 10)
+	assert.IsType(t, &container.IncompleteBlockError{}, err)
+	assert.Equal(t, complete, err.(*container.IncompleteBlockError).Offset)
+
+	appendWriter, err := NewPrimitiveTestRecordAppendWriter(file, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlock(file, appendWriter, rangeRecords(3, 3), t)
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGeneratedReader(file, rangeRecords(0, 6), t)
+}
+
+func TestAppendWriterDamagedBlock(t *testing.T) {
+	file, err := ioutil.TempFile("", "append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	containerWriter, err := NewPrimitiveTestRecordWriter(file, container.Null, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	complete := appendBlock(file, containerWriter, rangeRecords(0, 3), t)
+	appendBlock(file, containerWriter, rangeRecords(3, 3), t)
+
+	// Corrupt the sync marker of the first block, which is followed by a complete block
+	if _, err = file.WriteAt([]byte{0xff}, complete-1); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewPrimitiveTestRecordAppendWriter(file, 10)
+	assert.IsType(t, &container.SyncMarkerError{}, err)
+}
+
+func TestAppendWriterUnionSchema(t *testing.T) {
+	file, err := ioutil.TempFile("", "append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// Records can be appended to a file whose schema is a union of their schema and other types, as with WriteRecord
+	record := NewPrimitiveTestRecord()
+	_, err = container.NewWriter(file, container.Null, 10, `["string", `+record.Schema()+`]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendWriter, err := NewPrimitiveTestRecordAppendWriter(file, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, appendWriter.WriteRecord(record))
+	assert.Nil(t, appendWriter.Close())
+}
+
+func TestReadDatum(t *testing.T) {
+	fixtures := loadFixtures(t)
+	reader, err := container.NewReader(bytes.NewReader(writeContainer(fixtures, container.Null, 2, t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	avroType, err := schema.Parse([]byte(reader.Schema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range fixtures {
+		datumReader, err := reader.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		datum, err := schema.ReadDatum(avroType, datumReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, map[string]interface{}{
+			"IntField":    f.IntField,
+			"LongField":   f.LongField,
+			"FloatField":  f.FloatField,
+			"DoubleField": f.DoubleField,
+			"StringField": f.StringField,
+			"BoolField":   f.BoolField,
+			"BytesField":  f.BytesField,
+		}, datum)
+	}
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+// A FileFactory which creates in-memory files, so the tests can read them back
+type memoryFiles struct {
+	files map[string]*memoryFile
+	names []string
+}
+
+type memoryFile struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (m *memoryFile) Close() error {
+	m.closed = true
+	return nil
+}
+
+func (m *memoryFiles) create() (string, io.WriteCloser, error) {
+	name := fmt.Sprintf("file-%v.avro", len(m.names))
+	file := &memoryFile{}
+	if m.files == nil {
+		m.files = make(map[string]*memoryFile)
+	}
+	m.files[name] = file
+	m.names = append(m.names, name)
+	return name, file, nil
+}
+
+func writeRollingRecords(rollingWriter *container.RollingWriter, count int, t *testing.T) {
+	records := rangeRecords(0, count)
+	for i := range records {
+		err := rollingWriter.WriteRecord(&records[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := rollingWriter.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Read every record from the files in order, and check they hold the sequence of records written by writeRollingRecords
+func checkRolledFiles(files *memoryFiles, completed []container.RolledFile, count int, t *testing.T) {
+	assert.Equal(t, len(files.names), len(completed))
+
+	var i int32
+	for j, name := range files.names {
+		file := files.files[name]
+		assert.True(t, file.closed)
+		assert.Equal(t, name, completed[j].Name)
+		assert.Equal(t, int64(file.Len()), completed[j].Bytes)
+
+		reader, err := NewPrimitiveTestRecordReader(&file.Buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var records int64
+		for reader.Next() {
+			assert.Equal(t, i, reader.Record().IntField)
+			i = i + 1
+			records = records + 1
+		}
+		assert.Nil(t, reader.Err())
+		assert.Equal(t, completed[j].Records, records)
+	}
+	assert.Equal(t, int32(count), i)
+}
+
+func TestRollingWriterRecords(t *testing.T) {
+	files := &memoryFiles{}
+	var completed []container.RolledFile
+	rollingWriter, err := NewPrimitiveTestRecordRollingWriter(files.create, container.Deflate, 10,
+		container.RollAfterRecords(25),
+		container.OnFileComplete(func(f container.RolledFile) {
+			completed = append(completed, f)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeRollingRecords(rollingWriter, 110, t)
+	checkRolledFiles(files, completed, 110, t)
+	assert.Equal(t, 5, len(completed))
+	assert.Equal(t, int64(25), completed[0].Records)
+	assert.Equal(t, int64(10), completed[4].Records)
+	assert.Equal(t, container.ErrWriterClosed, rollingWriter.WriteRecord(NewPrimitiveTestRecord()))
+}
+
+func TestRollingWriterBytes(t *testing.T) {
+	files := &memoryFiles{}
+	var completed []container.RolledFile
+	rollingWriter, err := NewPrimitiveTestRecordRollingWriter(files.create, container.Null, 5,
+		container.RollAfterBytes(1000),
+		container.OnFileComplete(func(f container.RolledFile) {
+			completed = append(completed, f)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeRollingRecords(rollingWriter, 200, t)
+	checkRolledFiles(files, completed, 200, t)
+	assert.True(t, len(completed) > 1)
+	for _, f := range completed[:len(completed)-1] {
+		assert.True(t, f.Bytes >= 1000)
+	}
+}
+
+// The file size is updated by the compression goroutines while records are written, run with -race
+func TestRollingWriterBytesCompressionWorkers(t *testing.T) {
+	files := &memoryFiles{}
+	var completed []container.RolledFile
+	rollingWriter, err := NewPrimitiveTestRecordRollingWriter(files.create, container.Deflate, 5,
+		container.RollAfterBytes(1000),
+		container.WithFileWriterOptions(container.WithCompressionWorkers(4, 8)),
+		container.OnFileComplete(func(f container.RolledFile) {
+			completed = append(completed, f)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeRollingRecords(rollingWriter, 500, t)
+	checkRolledFiles(files, completed, 500, t)
+	assert.True(t, len(completed) > 1)
+	for _, f := range completed[:len(completed)-1] {
+		assert.True(t, f.Bytes >= 1000)
+	}
+}
+
+func TestRollingWriterDuration(t *testing.T) {
+	files := &memoryFiles{}
+	var completed []container.RolledFile
+	rollingWriter, err := NewPrimitiveTestRecordRollingWriter(files.create, container.Null, 5,
+		container.RollAfterDuration(10*time.Millisecond),
+		container.OnFileComplete(func(f container.RolledFile) {
+			completed = append(completed, f)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		record := NewPrimitiveTestRecord()
+		record.IntField = int32(i)
+		err = rollingWriter.WriteRecord(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	err = rollingWriter.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRolledFiles(files, completed, 3, t)
+	assert.Equal(t, 3, len(completed))
+}
+
+func TestRollingWriterNoRecords(t *testing.T) {
+	files := &memoryFiles{}
+	rollingWriter, err := NewPrimitiveTestRecordRollingWriter(files.create, container.Null, 5, container.RollAfterRecords(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, rollingWriter.Roll())
+	assert.Nil(t, rollingWriter.Close())
+	assert.Equal(t, 0, len(files.names))
+}
+
+// A record which fails to serialize
+type unserializableRecord struct {
+	PrimitiveTestRecord
+}
+
+func (u *unserializableRecord) Serialize(w io.Writer) error {
+	return io.ErrShortWrite
+}
+
+func TestRollingWriterFirstRecordError(t *testing.T) {
+	files := &memoryFiles{}
+	var completed []container.RolledFile
+	rollingWriter, err := NewPrimitiveTestRecordRollingWriter(files.create, container.Null, 5,
+		container.OnFileComplete(func(f container.RolledFile) {
+			completed = append(completed, f)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The record is serialized before a file is created for it
+	assert.Equal(t, io.ErrShortWrite, rollingWriter.WriteRecord(&unserializableRecord{}))
+	assert.Equal(t, 0, len(files.names))
+
+	writeRollingRecords(rollingWriter, 3, t)
+	checkRolledFiles(files, completed, 3, t)
+	assert.Equal(t, 1, len(completed))
+	assert.Equal(t, int64(3), completed[0].Records)
+}
+
+func TestWriterEquivalentSchema(t *testing.T) {
+	// The same schema with different formatting and key order
+	schema := `{"fields": [
+		{"type": "int", "name": "IntField"},
+		{"type": "long", "name": "LongField"},
+		{"type": "float", "name": "FloatField"},
+		{"type": "double", "name": "DoubleField"},
+		{"type": "string", "name": "StringField"},
+		{"type": "boolean", "name": "BoolField"},
+		{"type": "bytes", "name": "BytesField"}
+	], "name": "PrimitiveTestRecord", "type": "record"}`
+
+	containerWriter, err := container.NewWriter(ioutil.Discard, container.Null, 10, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, containerWriter.WriteRecord(NewPrimitiveTestRecord()))
+	assert.Nil(t, containerWriter.Close())
+}
+
+func TestDatumToJSON(t *testing.T) {
+	record := NewPrimitiveTestRecord()
+	record.IntField = -5
+	record.LongField = 1 << 40
+	record.FloatField = float32(math.Inf(1))
+	record.DoubleField = 0.25
+	record.StringField = "a \"quoted\" string"
+	record.BoolField = true
+	record.BytesField = []byte{0, 'a', 0xff}
+
+	var buf bytes.Buffer
+	if err := record.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	avroType, err := schema.Parse([]byte(record.Schema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	datum, err := schema.ReadDatum(avroType, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := schema.DatumToJSON(avroType, datum)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"IntField":-5,"LongField":1099511627776,"FloatField":"Infinity","DoubleField":0.25,"StringField":"a \"quoted\" string","BoolField":true,"BytesField":"\u0000aÿ"}`, string(encoded))
+}
+
+func TestDatumFromJSON(t *testing.T) {
+	record := NewPrimitiveTestRecord()
+	avroType, err := schema.Parse([]byte(record.Schema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	datum, err := schema.DatumFromJSON(avroType, []byte(`{"IntField":-5,"LongField":9007199254740993,"FloatField":"-Infinity","DoubleField":0.25,"StringField":"abc","BoolField":true,"BytesField":"\u0000a\u00ff"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = schema.WriteDatum(avroType, datum, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DeserializePrimitiveTestRecord(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, int32(-5), decoded.IntField)
+	assert.Equal(t, int64(9007199254740993), decoded.LongField)
+	assert.True(t, math.IsInf(float64(decoded.FloatField), -1))
+	assert.Equal(t, 0.25, decoded.DoubleField)
+	assert.Equal(t, "abc", decoded.StringField)
+	assert.Equal(t, true, decoded.BoolField)
+	assert.Equal(t, []byte{0, 'a', 0xff}, decoded.BytesField)
+
+	invalid := []string{
+		`{"IntField":2147483648,"LongField":1,"FloatField":1,"DoubleField":1,"StringField":"","BoolField":true,"BytesField":""}`,
+		`{"IntField":1.5,"LongField":1,"FloatField":1,"DoubleField":1,"StringField":"","BoolField":true,"BytesField":""}`,
+		`{"IntField":1,"LongField":1,"FloatField":1,"DoubleField":1,"StringField":"","BoolField":true,"BytesField":"\u0100"}`,
+		`{"IntField":1,"LongField":1,"FloatField":1,"DoubleField":1,"StringField":"","BoolField":true}`,
+		`{"IntField":1,"LongField":1,"FloatField":1,"DoubleField":1,"StringField":"","BoolField":true,"BytesField":"","Extra":1}`,
+	}
+	for _, line := range invalid {
+		_, err = schema.DatumFromJSON(avroType, []byte(line))
+		assert.NotNil(t, err, line)
+	}
+}
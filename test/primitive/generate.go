@@ -1,3 +1,3 @@
 package avro
 
-//go:generate $GOPATH/bin/gogen-avro --containers . primitives.avsc
+//go:generate $GOPATH/bin/gogen-avro --containers --single-object . primitives.avsc
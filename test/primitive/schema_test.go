@@ -2,11 +2,22 @@ package avro
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sync"
 	"testing"
 
+	"github.com/actgardner/gogen-avro/container"
+	"github.com/actgardner/gogen-avro/schema"
+	"github.com/actgardner/gogen-avro/serde"
+	"github.com/actgardner/gogen-avro/soe"
 	"github.com/linkedin/goavro"
 	"github.com/stretchr/testify/assert"
 )
@@ -132,6 +143,253 @@ func BenchmarkSerializePrimitiveGoavro(b *testing.B) {
 	}
 }
 
+func TestCanonicalForm(t *testing.T) {
+	schemas := map[string]string{
+		`"int"`: `"int"`,
+		`{"type": "array", "items": {"type": "string"}}`: `{"type":"array","items":"string"}`,
+		`{"type": "record", "name": "R", "namespace": "a.b", "doc": "Dropped", "custom": 1, "fields": [
+			{"name": "e", "type": {"type": "enum", "name": "E", "symbols": ["X", "Y"]}, "doc": "Dropped"},
+			{"name": "e2", "type": "E", "default": "X"},
+			{"name": "f", "type": {"type": "fixed", "name": "c.F", "size": 4}},
+			{"name": "u", "type": ["null", {"type": "map", "values": "long"}]}
+		]}`: `{"name":"a.b.R","type":"record","fields":[{"name":"e","type":{"name":"a.b.E","type":"enum","symbols":["X","Y"]}},{"name":"e2","type":"a.b.E"},{"name":"f","type":{"name":"c.F","type":"fixed","size":4}},{"name":"u","type":["null",{"type":"map","values":"long"}]}]}`,
+	}
+
+	for schemaJson, expected := range schemas {
+		avroType, err := schema.Parse([]byte(schemaJson))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		canonical, err := schema.CanonicalForm(avroType)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, canonical)
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	// Fingerprints from the Avro spec's test schemas
+	assert.Equal(t, uint64(8247732601305521295), schema.Fingerprint64([]byte(`"int"`)))
+	assert.Equal(t, uint64(7195948357588979594), schema.Fingerprint64([]byte(`"null"`)))
+
+	record := NewPrimitiveTestRecord()
+	avroType, err := schema.Parse([]byte(record.Schema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canonical, err := schema.CanonicalForm(avroType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, canonical, record.CanonicalSchema())
+	assert.Equal(t, schema.Fingerprint64([]byte(canonical)), record.Fingerprint64())
+	assert.Equal(t, md5.Sum([]byte(canonical)), record.FingerprintMD5())
+	assert.Equal(t, sha256.Sum256([]byte(canonical)), record.FingerprintSHA256())
+}
+
+func TestParseRedefinedType(t *testing.T) {
+	// Named types can be defined again, as generated schemas do, as long as every definition is the same
+	redefined := `{"type": "record", "name": "R", "fields": [
+		{"name": "a", "type": {"type": "fixed", "name": "F", "aliases": ["G"], "size": 4}},
+		{"name": "b", "type": {"type": "fixed", "name": "F", "aliases": ["G"], "size": 4}},
+		{"name": "c", "type": "G"}
+	]}`
+
+	avroType, err := schema.Parse([]byte(redefined))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canonical, err := schema.CanonicalForm(avroType)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"R","type":"record","fields":[{"name":"a","type":{"name":"F","type":"fixed","size":4}},{"name":"b","type":"F"},{"name":"c","type":"F"}]}`, canonical)
+
+	conflicting := `{"type": "record", "name": "R", "fields": [
+		{"name": "a", "type": {"type": "fixed", "name": "F", "size": 4}},
+		{"name": "b", "type": {"type": "fixed", "name": "F", "size": 8}}
+	]}`
+
+	_, err = schema.Parse([]byte(conflicting))
+	assert.NotNil(t, err)
+
+	conflictingAlias := `{"type": "record", "name": "R", "fields": [
+		{"name": "a", "type": {"type": "fixed", "name": "F", "size": 4}},
+		{"name": "b", "type": {"type": "fixed", "name": "G", "aliases": ["F"], "size": 4}}
+	]}`
+
+	_, err = schema.Parse([]byte(conflictingAlias))
+	assert.NotNil(t, err)
+}
+
+func TestSingleObjectEncoding(t *testing.T) {
+	record := NewPrimitiveTestRecord()
+	record.IntField = 12
+	record.StringField = "single"
+	record.BytesField = []byte{1, 2}
+
+	data, err := record.MarshalSingleObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprint := make([]byte, 8)
+	binary.LittleEndian.PutUint64(fingerprint, record.Fingerprint64())
+	assert.Equal(t, []byte{0xc3, 0x01}, data[:2])
+	assert.Equal(t, fingerprint, data[2:10])
+
+	decoded, err := UnmarshalPrimitiveTestRecordSingleObject(data, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, record, decoded)
+
+	_, err = UnmarshalPrimitiveTestRecordSingleObject(data[1:], nil)
+	assert.Equal(t, soe.ErrInvalidMarker, err)
+
+	// Change the fingerprint to one which isn't in the store
+	data[2] ^= 0xff
+	_, err = UnmarshalPrimitiveTestRecordSingleObject(data, soe.NewSchemaStore())
+	assert.Equal(t, soe.NewUnknownFingerprintError(record.Fingerprint64()^0xff), err)
+}
+
+func TestSerdeWireFormat(t *testing.T) {
+	record := NewPrimitiveTestRecord()
+	record.IntField = 34
+	record.StringField = "framed"
+	record.BytesField = []byte{3, 4}
+
+	registry := serde.NewMemoryRegistry()
+	serializer := serde.NewSerializer(registry, nil, false)
+	data, err := serializer.Serialize("primitives", record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []byte{0, 0, 0, 0, 1}, data[:serde.HeaderSize])
+	assert.Equal(t, []int32{1}, registry.SubjectIDs("primitives-value"))
+
+	schema, r, err := serde.NewDeserializer(registry).Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record.Schema(), schema)
+
+	decoded, err := DeserializePrimitiveTestRecordFromSchema(r, schema)
+	assert.Nil(t, err)
+	assert.Equal(t, record, decoded)
+
+	// The same schema written to another topic is registered under a new subject with the same ID
+	data, err = serializer.Serialize("other", record)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 1}, data[:serde.HeaderSize])
+	assert.Equal(t, []int32{1}, registry.SubjectIDs("other-value"))
+
+	_, _, err = serde.NewDeserializer(serde.NewMemoryRegistry()).Deserialize(data)
+	assert.Equal(t, serde.NewUnknownSchemaIDError(1), err)
+
+	data[0] = 1
+	_, _, err = serde.NewDeserializer(registry).Deserialize(data)
+	assert.Equal(t, serde.ErrInvalidMagicByte, err)
+}
+
+func TestSubjectNameStrategies(t *testing.T) {
+	record := NewPrimitiveTestRecord()
+
+	subject, err := serde.TopicNameStrategy("events", true, record)
+	assert.Nil(t, err)
+	assert.Equal(t, "events-key", subject)
+
+	subject, err = serde.RecordNameStrategy("events", false, record)
+	assert.Nil(t, err)
+	assert.Equal(t, "PrimitiveTestRecord", subject)
+
+	subject, err = serde.TopicRecordNameStrategy("events", false, record)
+	assert.Nil(t, err)
+	assert.Equal(t, "events-PrimitiveTestRecord", subject)
+}
+
+func TestSerializerSubjectCache(t *testing.T) {
+	// The strategy is only called once for each topic and schema
+	calls := 0
+	strategy := func(topic string, isKey bool, record container.AvroRecord) (string, error) {
+		calls++
+		return serde.TopicRecordNameStrategy(topic, isKey, record)
+	}
+
+	registry := serde.NewMemoryRegistry()
+	serializer := serde.NewSerializer(registry, strategy, false)
+	record := NewPrimitiveTestRecord()
+	for _, topic := range []string{"events", "events", "other", "events"} {
+		if _, err := serializer.Serialize(topic, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []int32{1}, registry.SubjectIDs("events-PrimitiveTestRecord"))
+	assert.Equal(t, []int32{1}, registry.SubjectIDs("other-PrimitiveTestRecord"))
+}
+
+func TestFileRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "registry.json")
+	registry, err := serde.NewFileRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := NewPrimitiveTestRecord()
+	id, err := registry.Register("primitives-value", record.Schema())
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), id)
+
+	// A schema which only differs in formatting has the same canonical form, so it keeps the same ID
+	var indented bytes.Buffer
+	if err = json.Indent(&indented, []byte(record.Schema()), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := serde.NewFileRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err = reopened.Register("primitives-value", indented.String())
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), id)
+
+	schema, err := reopened.Schema(1)
+	assert.Nil(t, err)
+	assert.Equal(t, record.Schema(), schema)
+
+	_, err = reopened.Schema(2)
+	assert.Equal(t, serde.NewUnknownSchemaIDError(2), err)
+
+	// Every concurrent registration is saved
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := reopened.Register(fmt.Sprintf("subject-%v", i), record.Schema())
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	reopened, err = serde.NewFileRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 11, len(reopened.Subjects()))
+	assert.Equal(t, []int32{1}, reopened.SubjectIDs("subject-9"))
+}
+
 func BenchmarkDeserializePrimitiveRecord(b *testing.B) {
 	buf := new(bytes.Buffer)
 	record := PrimitiveTestRecord{1, 2, 3.4, 5.6, "789", true, []byte{1, 2, 3, 4}}
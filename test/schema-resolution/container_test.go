@@ -0,0 +1,163 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/actgardner/gogen-avro/container"
+	"github.com/actgardner/gogen-avro/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixtureRecord writes a record serialized with writerSchema to a container file
+type fixtureRecord struct {
+	schema string
+	data   []byte
+}
+
+func (f *fixtureRecord) Schema() string {
+	return f.schema
+}
+
+func (f *fixtureRecord) Serialize(w io.Writer) error {
+	_, err := w.Write(f.data)
+	return err
+}
+
+func TestReaderResolvesSchema(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := container.NewWriter(&buf, container.Deflate, 2, writerSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := &fixtureRecord{schema: writerSchema, data: serializeWithSchema(writerSchema, writerFixture, t).Bytes()}
+	for i := 0; i < 5; i++ {
+		if err = writer.WriteRecord(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewResolutionTestRecordReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var i int
+	for reader.Next() {
+		checkResolvedRecord(reader.Record(), t)
+		i = i + 1
+	}
+	assert.Nil(t, reader.Err())
+	assert.Equal(t, 5, i)
+}
+
+func TestReaderUnresolvableSchema(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := container.NewWriter(&buf, container.Null, 2, `{"type": "record", "name": "OldTestRecord", "fields": [{"name": "IntField", "type": "string"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewResolutionTestRecordReader(&buf)
+	assert.IsType(t, &container.SchemaMismatchError{}, err)
+}
+
+func TestResolveDatum(t *testing.T) {
+	resolver, err := schema.NewResolver(`["null", "int"]`, `["string", "null", "double"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	datum, err := resolver.ResolveDatum(map[string]interface{}{"int": int32(3)})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"double": float64(3)}, datum)
+
+	datum, err = resolver.ResolveDatum(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, datum)
+}
+
+func TestResolveWriterUnion(t *testing.T) {
+	// A writer union can be read if any branch can be, and values of the other branches fail when they're resolved
+	resolver, err := schema.NewResolver(`["null", "int"]`, `"long"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	datum, err := resolver.ResolveDatum(map[string]interface{}{"int": int32(3)})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), datum)
+
+	_, err = resolver.ResolveDatum(nil)
+	assert.Equal(t, &schema.ResolutionError{Path: "long", Message: "Writer type null can't be read as long"}, err)
+
+	_, err = schema.NewResolver(`["null", "boolean"]`, `"long"`)
+	assert.IsType(t, &schema.ResolutionError{}, err)
+
+	// Compatibility checks require every branch to be readable
+	versions := parseVersions([]string{`["null", "int"]`, `"long"`}, t)
+	assert.Equal(t, []*schema.ResolutionError{
+		{Path: "long", Message: "Writer type null can't be read as long"},
+	}, schema.ResolutionErrors(versions[0], versions[1]))
+}
+
+func TestResolveNestedDefaults(t *testing.T) {
+	readerSchema := `{"type": "record", "name": "R", "fields": [
+		{"name": "a", "type": "int"},
+		{"name": "nested", "type": {"type": "record", "name": "N", "fields": [{"name": "u", "type": ["string", "null"]}]}, "default": {"u": "x"}},
+		{"name": "items", "type": {"type": "array", "items": ["int", "null"]}, "default": [1, 2]},
+		{"name": "optional", "type": ["null", "N"], "default": null}
+	]}`
+
+	resolver, err := schema.NewResolver(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`, readerSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"a":        int32(1),
+		"nested":   map[string]interface{}{"u": map[string]interface{}{"string": "x"}},
+		"items":    []interface{}{map[string]interface{}{"int": int32(1)}, map[string]interface{}{"int": int32(2)}},
+		"optional": nil,
+	}
+
+	datum, err := resolver.ResolveDatum(map[string]interface{}{"a": int32(1)})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, datum)
+
+	// Each resolved datum has its own copy of the defaults
+	datum.(map[string]interface{})["nested"].(map[string]interface{})["u"] = nil
+	datum, err = resolver.ResolveDatum(map[string]interface{}{"a": int32(1)})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, datum)
+
+	// DatumFromJSON fills in missing fields the same way
+	avroType, err := schema.Parse([]byte(readerSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	datum, err = schema.DatumFromJSON(avroType, []byte(`{"a": 1}`))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, datum)
+
+	// Invalid defaults are found when the resolver is created
+	_, err = schema.NewResolver(`{"type": "record", "name": "R", "fields": []}`, `{"type": "record", "name": "R", "fields": [{"name": "b", "type": "int", "default": "abc"}]}`)
+	assert.IsType(t, &schema.ResolutionError{}, err)
+	assert.Equal(t, "R.b", err.(*schema.ResolutionError).Path)
+
+	// The default of a union must be a value of its first branch
+	_, err = schema.NewResolver(`{"type": "record", "name": "R", "fields": []}`, `{"type": "record", "name": "R", "fields": [{"name": "b", "type": ["null", "int"], "default": 5}]}`)
+	assert.IsType(t, &schema.ResolutionError{}, err)
+	assert.Equal(t, "R.b", err.(*schema.ResolutionError).Path)
+}
@@ -0,0 +1,249 @@
+package avro
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/actgardner/gogen-avro/schema"
+	"github.com/actgardner/gogen-avro/serde"
+	"github.com/actgardner/gogen-avro/soe"
+	"github.com/stretchr/testify/assert"
+)
+
+// An older version of reader.avsc, which the records in these tests are written with
+const writerSchema = `
+{
+  "type": "record",
+  "name": "OldTestRecord",
+  "fields": [
+    {"name": "IntField", "type": "int"},
+    {"name": "LongField", "type": "int"},
+    {"name": "DoubleField", "type": "float"},
+    {"name": "StringField", "type": "bytes"},
+    {"name": "OldNameField", "type": "string"},
+    {"name": "RemovedField", "type": {"type": "map", "values": "string"}},
+    {"name": "EnumField", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN", "BLUE"]}},
+    {"name": "UnionField", "type": ["int", "string"]},
+    {"name": "OptionalField", "type": "string"},
+    {"name": "ArrayField", "type": {"type": "array", "items": "int"}},
+    {"name": "NestedField", "type": {"type": "record", "name": "NestedRecord", "fields": [{"name": "A", "type": "int"}, {"name": "B", "type": "string"}]}}
+  ]
+}
+`
+
+const writerFixture = `{"IntField": 1, "LongField": 2, "DoubleField": 3.5, "StringField": "abc", "OldNameField": "renamed", "RemovedField": {"a": "b"}, "EnumField": "BLUE", "UnionField": {"int": 4}, "OptionalField": "optional", "ArrayField": [5, 6], "NestedField": {"A": 7, "B": "removed"}}`
+
+func serializeWithSchema(writerSchema, fixture string, t *testing.T) *bytes.Buffer {
+	avroType, err := schema.Parse([]byte(writerSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	datum, err := schema.DatumFromJSON(avroType, []byte(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = schema.WriteDatum(avroType, datum, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func checkResolvedRecord(record *ResolutionTestRecord, t *testing.T) {
+	assert.Equal(t, int32(1), record.IntField)
+	assert.Equal(t, int64(2), record.LongField)
+	assert.Equal(t, 3.5, record.DoubleField)
+	assert.Equal(t, "abc", record.StringField)
+	assert.Equal(t, "renamed", record.RenamedField)
+	assert.Equal(t, "added", record.AddedField)
+	assert.Equal(t, UnionNullIntTypeEnumNull, record.AddedUnionField.UnionType)
+	assert.Equal(t, ColorUNKNOWN, record.EnumField)
+	assert.Equal(t, UnionNullLongStringTypeEnumLong, record.UnionField.UnionType)
+	assert.Equal(t, int64(4), record.UnionField.Long)
+	assert.Equal(t, UnionNullStringTypeEnumString, record.OptionalField.UnionType)
+	assert.Equal(t, "optional", record.OptionalField.String)
+	assert.Equal(t, []float64{5, 6}, record.ArrayField)
+	assert.Equal(t, &NestedRecord{A: 7}, record.NestedField)
+}
+
+func TestDeserializeFromSchema(t *testing.T) {
+	buf := serializeWithSchema(writerSchema, writerFixture, t)
+
+	record, err := DeserializeResolutionTestRecordFromSchema(buf, writerSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResolvedRecord(record, t)
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestDeserializeFromSameSchema(t *testing.T) {
+	record := NewResolutionTestRecord()
+	record.StringField = "abc"
+	record.NestedField = &NestedRecord{A: 1}
+
+	var buf bytes.Buffer
+	if err := record.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DeserializeResolutionTestRecordFromSchema(&buf, record.Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record, decoded)
+}
+
+func TestDeserializeFromIncompatibleSchema(t *testing.T) {
+	incompatible := []string{
+		// IntField has no default
+		`{"type": "record", "name": "ResolutionTestRecord", "fields": []}`,
+		// The record name doesn't match the reader's name or aliases
+		`{"type": "record", "name": "OtherRecord", "fields": [{"name": "IntField", "type": "int"}]}`,
+		// A long can't be read as an int
+		`{"type": "record", "name": "OldTestRecord", "fields": [{"name": "IntField", "type": "long"}]}`,
+	}
+
+	for _, writerSchema := range incompatible {
+		_, err := DeserializeResolutionTestRecordFromSchema(&bytes.Buffer{}, writerSchema)
+		assert.IsType(t, &schema.ResolutionError{}, err, writerSchema)
+	}
+}
+
+func TestResolutionErrorPath(t *testing.T) {
+	writerSchema := `{"type": "record", "name": "OldTestRecord", "fields": [
+		{"name": "IntField", "type": "int"},
+		{"name": "LongField", "type": "int"},
+		{"name": "DoubleField", "type": "float"},
+		{"name": "StringField", "type": "bytes"},
+		{"name": "OldNameField", "type": "string"},
+		{"name": "EnumField", "type": {"type": "enum", "name": "Color", "symbols": ["RED"]}},
+		{"name": "UnionField", "type": ["int", "boolean"]}
+	]}`
+
+	// UnionField can be read when it was written with the int branch, so the first error is the missing field
+	_, err := schema.NewResolver(writerSchema, NewResolutionTestRecord().Schema())
+	assert.Equal(t, &schema.ResolutionError{Path: "ResolutionTestRecord.OptionalField", Message: "Field is missing from the writer schema and has no default"}, err)
+}
+
+func parseVersions(schemas []string, t *testing.T) []*schema.Type {
+	versions := make([]*schema.Type, len(schemas))
+	for i, schemaJson := range schemas {
+		var err error
+		if versions[i], err = schema.Parse([]byte(schemaJson)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return versions
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	versions := parseVersions([]string{writerSchema, NewResolutionTestRecord().Schema()}, t)
+
+	assert.Equal(t, []schema.Incompatibility{}, schema.CheckCompatibility(schema.Backward, versions))
+	assert.Equal(t, []schema.Incompatibility{}, schema.CheckCompatibility(schema.None, versions))
+
+	// The old schema's name isn't one of the new schema's aliases, so the old schema can't read anything written with the new one
+	assert.Equal(t, []schema.Incompatibility{
+		{WriterVersion: 1, ReaderVersion: 0, Path: "OldTestRecord", Message: "Writer type ResolutionTestRecord can't be read as OldTestRecord"},
+	}, schema.CheckCompatibility(schema.Forward, versions))
+
+	versions[0] = parseVersions([]string{strings.Replace(writerSchema, "OldTestRecord", "ResolutionTestRecord", 1)}, t)[0]
+
+	forward := []schema.Incompatibility{
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.LongField", Message: "Writer type long can't be read as int"},
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.DoubleField", Message: "Writer type double can't be read as float"},
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.OldNameField", Message: "Field is missing from the writer schema and has no default"},
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.RemovedField", Message: "Field is missing from the writer schema and has no default"},
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.EnumField", Message: `Writer symbol "UNKNOWN" isn't in the reader enum, which has no default`},
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.UnionField", Message: "Reader union has no branch for writer type null"},
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.UnionField", Message: "Reader union has no branch for writer type long"},
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.OptionalField", Message: "Writer type null can't be read as string"},
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.ArrayField[]", Message: "Writer type double can't be read as int"},
+		{WriterVersion: 1, ReaderVersion: 0, Path: "ResolutionTestRecord.NestedField.B", Message: "Field is missing from the writer schema and has no default"},
+	}
+	assert.Equal(t, forward, schema.CheckCompatibility(schema.Forward, versions))
+	assert.Equal(t, forward, schema.CheckCompatibility(schema.Full, versions))
+}
+
+func TestCheckCompatibilityTransitive(t *testing.T) {
+	versions := parseVersions([]string{
+		`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`,
+		`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "b", "type": "string", "default": ""}]}`,
+		`{"type": "record", "name": "R", "fields": [{"name": "b", "type": "string"}]}`,
+	}, t)
+
+	assert.Equal(t, []schema.Incompatibility{}, schema.CheckCompatibility(schema.Backward, versions))
+	assert.Equal(t, []schema.Incompatibility{
+		{WriterVersion: 0, ReaderVersion: 2, Path: "R.b", Message: "Field is missing from the writer schema and has no default"},
+	}, schema.CheckCompatibility(schema.BackwardTransitive, versions))
+
+	// A new field whose default isn't a value of the first branch of its union can't be filled in
+	versions = parseVersions([]string{
+		`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`,
+		`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "b", "type": ["null", "int"], "default": 5}]}`,
+	}, t)
+	assert.Equal(t, []schema.Incompatibility{
+		{WriterVersion: 0, ReaderVersion: 1, Path: "R.b", Message: "Invalid default value: Default value 5 doesn't match the first branch of the union, null"},
+	}, schema.CheckCompatibility(schema.Backward, versions))
+
+	mode, err := schema.ParseCompatibilityMode("full_transitive")
+	assert.Nil(t, err)
+	assert.Equal(t, schema.FullTransitive, mode)
+	assert.Equal(t, "FULL_TRANSITIVE", mode.String())
+
+	_, err = schema.ParseCompatibilityMode("SIDEWAYS")
+	assert.NotNil(t, err)
+}
+
+func TestUnmarshalSingleObjectFromStore(t *testing.T) {
+	store := soe.NewSchemaStore()
+	fingerprint, err := store.Add(writerSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = soe.WriteHeader(&buf, fingerprint); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(serializeWithSchema(writerSchema, writerFixture, t).Bytes())
+
+	record, err := UnmarshalResolutionTestRecordSingleObject(buf.Bytes(), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResolvedRecord(record, t)
+
+	_, err = UnmarshalResolutionTestRecordSingleObject(buf.Bytes(), nil)
+	assert.Equal(t, soe.NewUnknownFingerprintError(fingerprint), err)
+}
+
+func TestSerdeResolvesWriterSchema(t *testing.T) {
+	registry := serde.NewMemoryRegistry()
+	id, err := registry.Register("resolution-value", writerSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = serde.WriteHeader(&buf, id); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(serializeWithSchema(writerSchema, writerFixture, t).Bytes())
+
+	schema, r, err := serde.NewDeserializer(registry).Deserialize(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, writerSchema, schema)
+
+	record, err := DeserializeResolutionTestRecordFromSchema(r, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResolvedRecord(record, t)
+}
@@ -3,9 +3,12 @@ package avro
 import (
 	"bytes"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"testing"
 
 	"github.com/actgardner/gogen-avro/container"
+	"github.com/actgardner/gogen-avro/schema"
 	"github.com/linkedin/goavro"
 	"github.com/stretchr/testify/assert"
 )
@@ -95,3 +98,81 @@ func roundTripWithCodec(codec container.Codec, t *testing.T) {
 		i = i + 1
 	}
 }
+
+// A record which isn't one of the branches of the root union
+type otherRecord struct{}
+
+func (o *otherRecord) Schema() string {
+	return `{"type": "record", "name": "other", "fields": []}`
+}
+
+func (o *otherRecord) Serialize(w io.Writer) error {
+	return nil
+}
+
+// A record with no schema at all
+type emptySchemaRecord struct {
+	otherRecord
+}
+
+func (e *emptySchemaRecord) Schema() string {
+	return ""
+}
+
+func TestUnionRootContainer(t *testing.T) {
+	unionSchema, err := ioutil.ReadFile("union.avsc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	containerWriter, err := container.NewWriter(&buf, container.Deflate, 10, string(unionSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.IsType(t, &container.SchemaMismatchError{}, containerWriter.WriteRecord(&emptySchemaRecord{}))
+
+	for _, f := range fixtures {
+		err = containerWriter.WriteRecord(&f)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.IsType(t, &container.SchemaMismatchError{}, containerWriter.WriteRecord(&otherRecord{}))
+	assert.Nil(t, containerWriter.Close())
+
+	// Each record should be written as the event branch of the union
+	reader, err := container.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	avroType, err := schema.Parse([]byte(reader.Schema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range fixtures {
+		datumReader, err := reader.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		datum, err := schema.ReadDatum(avroType, datumReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, map[string]interface{}{
+			"event": map[string]interface{}{
+				"id":       f.Id,
+				"start_ip": f.Start_ip[:],
+				"end_ip":   f.End_ip[:],
+			},
+		}, datum)
+	}
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
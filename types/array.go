@@ -65,6 +65,14 @@ func NewArrayField(itemType AvroType, definition map[string]interface{}) *arrayF
 	}
 }
 
+// The type of the array's items, if avroType is an array
+func ArrayItemType(avroType AvroType) (AvroType, bool) {
+	if array, ok := avroType.(*arrayField); ok {
+		return array.itemType, true
+	}
+	return nil, false
+}
+
 func (s *arrayField) Name() string {
 	return "Array" + s.itemType.Name()
 }
@@ -26,3 +26,35 @@ type AvroType interface {
 	Definition(scope map[QualifiedName]interface{}) (interface{}, error)
 	DefaultValue(lvalue string, rvalue interface{}) (string, error)
 }
+
+// The Avro name of a type: the type name of primitives, "array", "map" or "union", or the full name of a named type
+// once references have been resolved
+func AvroTypeName(avroType AvroType) string {
+	switch t := avroType.(type) {
+	case *Reference:
+		return t.def.AvroName().String()
+	case *nullField:
+		return "null"
+	case *boolField:
+		return "boolean"
+	case *intField:
+		return "int"
+	case *longField:
+		return "long"
+	case *floatField:
+		return "float"
+	case *doubleField:
+		return "double"
+	case *bytesField:
+		return "bytes"
+	case *stringField:
+		return "string"
+	case *arrayField:
+		return "array"
+	case *mapField:
+		return "map"
+	case *unionField:
+		return "union"
+	}
+	return avroType.Name()
+}
@@ -0,0 +1,164 @@
+package types
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+/*
+  Avro Parsing Canonical Form, which strips a schema down to the parts which affect how data is read and written,
+  so two schemas which differ only in documentation, aliases, defaults, custom attributes or formatting have the same form.
+  Names are replaced with full names, so the same type declared in different namespaces has a different form.
+  Fingerprints of the canonical form identify a schema cheaply, for example in the single-object encoding.
+*/
+
+// The value of the Rabin fingerprint of the empty string, from the Avro spec
+const crc64AvroEmpty uint64 = 0xc15d213aa4d7a795
+
+var crc64AvroTable = makeCRC64AvroTable()
+
+func makeCRC64AvroTable() [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (crc64AvroEmpty & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return table
+}
+
+// Write a type in Parsing Canonical Form. Named types are written with their full names, and only the attributes which
+// affect the binary encoding are kept.
+func CanonicalForm(avroType AvroType) (string, error) {
+	var buf bytes.Buffer
+	if err := appendCanonicalForm(&buf, avroType, make(map[QualifiedName]bool)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// The CRC-64-AVRO (Rabin) fingerprint of a schema's canonical form
+func Fingerprint64(canonicalForm []byte) uint64 {
+	fp := crc64AvroEmpty
+	for _, b := range canonicalForm {
+		fp = (fp >> 8) ^ crc64AvroTable[byte(fp)^b]
+	}
+	return fp
+}
+
+// The MD5 fingerprint of a schema's canonical form
+func FingerprintMD5(canonicalForm []byte) [md5.Size]byte {
+	return md5.Sum(canonicalForm)
+}
+
+// The SHA-256 fingerprint of a schema's canonical form
+func FingerprintSHA256(canonicalForm []byte) [sha256.Size]byte {
+	return sha256.Sum256(canonicalForm)
+}
+
+func appendCanonicalForm(buf *bytes.Buffer, avroType AvroType, defined map[QualifiedName]bool) error {
+	switch t := avroType.(type) {
+	case *Reference:
+		return appendCanonicalDefinition(buf, t.def, defined)
+	case *arrayField:
+		buf.WriteString(`{"type":"array","items":`)
+		if err := appendCanonicalForm(buf, t.itemType, defined); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case *mapField:
+		buf.WriteString(`{"type":"map","values":`)
+		if err := appendCanonicalForm(buf, t.itemType, defined); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case *unionField:
+		buf.WriteByte('[')
+		for i, itemType := range t.itemType {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := appendCanonicalForm(buf, itemType, defined); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case *nullField, *boolField, *intField, *longField, *floatField, *doubleField, *bytesField, *stringField:
+		return appendCanonicalString(buf, AvroTypeName(avroType))
+	}
+	return fmt.Errorf("Unable to write canonical form of type %v", avroType.Name())
+}
+
+func appendCanonicalDefinition(buf *bytes.Buffer, def Definition, defined map[QualifiedName]bool) error {
+	name := def.AvroName()
+
+	// Named types are defined the first time they're used, and referred to by their full name after that
+	if defined[name] {
+		return appendCanonicalString(buf, name.String())
+	}
+	defined[name] = true
+
+	buf.WriteString(`{"name":`)
+	if err := appendCanonicalString(buf, name.String()); err != nil {
+		return err
+	}
+
+	switch d := def.(type) {
+	case *RecordDefinition:
+		buf.WriteString(`,"type":"record","fields":[`)
+		for i, f := range d.fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			buf.WriteString(`{"name":`)
+			if err := appendCanonicalString(buf, f.Name()); err != nil {
+				return err
+			}
+			buf.WriteString(`,"type":`)
+			if err := appendCanonicalForm(buf, f.Type(), defined); err != nil {
+				return err
+			}
+			buf.WriteByte('}')
+		}
+		buf.WriteString(`]}`)
+	case *EnumDefinition:
+		buf.WriteString(`,"type":"enum","symbols":[`)
+		for i, symbol := range d.symbols {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := appendCanonicalString(buf, symbol); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(`]}`)
+	case *FixedDefinition:
+		fmt.Fprintf(buf, `,"type":"fixed","size":%d}`, d.sizeBytes)
+	default:
+		return fmt.Errorf("Unable to write canonical form of type %v", name)
+	}
+	return nil
+}
+
+// Strings are written with characters as UTF-8 rather than escaped where JSON allows it
+func appendCanonicalString(buf *bytes.Buffer, s string) error {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+
+	// Encode adds a newline after the value
+	buf.Write(bytes.TrimRight(encoded.Bytes(), "\n"))
+	return nil
+}
@@ -64,6 +64,16 @@ func (e *EnumDefinition) Aliases() []QualifiedName {
 	return e.aliases
 }
 
+func (e *EnumDefinition) Symbols() []string {
+	return e.symbols
+}
+
+// The symbol readers use for symbols which aren't in the enum, or an empty string if the enum has no default
+func (e *EnumDefinition) Default() string {
+	symbol, _ := e.definition["default"].(string)
+	return symbol
+}
+
 func (e *EnumDefinition) GoType() string {
 	return generator.ToPublicName(e.name.Name)
 }
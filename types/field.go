@@ -34,13 +34,20 @@ func (f *Field) GoName() string {
 }
 
 func (f *Field) HasDefault() bool {
-	return f.defValue == nil
+	return f.hasDef
 }
 
 func (f *Field) Default() interface{} {
 	return f.defValue
 }
 
+// The aliases of the field, which readers use to find fields which have been renamed
+func (f *Field) Aliases() []string {
+	aliases, _ := f.definition["aliases"].([]interface{})
+	aliasStrings, _ := interfaceSliceToStringSlice(aliases)
+	return aliasStrings
+}
+
 func (f *Field) Type() AvroType {
 	return f.avroType
 }
@@ -48,6 +48,10 @@ func (s *FixedDefinition) Aliases() []QualifiedName {
 	return s.aliases
 }
 
+func (s *FixedDefinition) SizeBytes() int {
+	return s.sizeBytes
+}
+
 func (s *FixedDefinition) GoType() string {
 	return generator.ToPublicName(s.name.Name)
 }
@@ -71,6 +71,14 @@ func NewMapField(itemType AvroType, definition map[string]interface{}) *mapField
 	}
 }
 
+// The type of the map's values, if avroType is a map
+func MapValueType(avroType AvroType) (AvroType, bool) {
+	if m, ok := avroType.(*mapField); ok {
+		return m.itemType, true
+	}
+	return nil, false
+}
+
 func (s *mapField) Name() string {
 	return "Map" + s.itemType.Name()
 }
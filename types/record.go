@@ -1,10 +1,12 @@
 package types
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/actgardner/gogen-avro/generator"
 	"strconv"
+	"strings"
 )
 
 const recordStructDefTemplate = `type %v struct {
@@ -17,6 +19,34 @@ const recordSchemaTemplate = `func (r %v) Schema() string {
 }
 `
 
+const recordCanonicalSchemaTemplate = `
+// The Parsing Canonical Form of the schema, which the fingerprints are calculated from
+func (r %v) CanonicalSchema() string {
+	return %v
+}
+`
+
+const recordFingerprint64Template = `
+// The CRC-64-AVRO fingerprint of the schema's Parsing Canonical Form
+func (r %v) Fingerprint64() uint64 {
+	return %#x
+}
+`
+
+const recordFingerprintMD5Template = `
+// The MD5 fingerprint of the schema's Parsing Canonical Form
+func (r %v) FingerprintMD5() [16]byte {
+	return [16]byte{%v}
+}
+`
+
+const recordFingerprintSHA256Template = `
+// The SHA-256 fingerprint of the schema's Parsing Canonical Form
+func (r %v) FingerprintSHA256() [32]byte {
+	return [32]byte{%v}
+}
+`
+
 const recordConstructorTemplate = `
 	func %v %v {
 		v := &%v{
@@ -48,10 +78,158 @@ func %v(r io.Reader) (%v, error) {
 }
 `
 
+const recordStructFromSchemaDeserializerTemplate = `
+// Deserialize a %v written with writerSchema, such as the schema from a container file header.
+// Differences from the schema %v was generated from are resolved with Avro's schema resolution rules.
+func %v(r io.Reader, writerSchema string) (%v, error) {
+	str := &%v{}
+	if writerSchema == str.Schema() {
+		return %v(r)
+	}
+
+	resolver, err := schema.CachedResolver(writerSchema, str.Schema())
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := resolver.TranscodeReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return %v(resolved)
+}
+`
+
+const recordMarshalSingleObjectTemplate = `
+// Serialize the record in the single-object encoding: a marker, the fingerprint of the record's schema and the binary encoding of the record
+func (r %v) MarshalSingleObject() ([]byte, error) {
+	var buf bytes.Buffer
+	err := soe.WriteHeader(&buf, r.Fingerprint64())
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.Serialize(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+`
+
+const recordUnmarshalSingleObjectTemplate = `
+// Deserialize a %v from the single-object encoding. Messages written with a different schema are resolved to %v's schema
+// if their schema is in store, which may be nil. Otherwise a soe.UnknownFingerprintError is returned.
+func %v(data []byte, store *soe.SchemaStore) (%v, error) {
+	r := bytes.NewReader(data)
+	fingerprint, err := soe.ReadHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	str := &%v{}
+	if fingerprint == str.Fingerprint64() {
+		return %v(r)
+	}
+
+	writerSchema, err := store.Lookup(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	return %v(r, writerSchema)
+}
+`
+
 const recordWriterTemplate = `
-func %v(writer io.Writer, codec container.Codec, recordsPerBlock int64) (*container.Writer, error) {
+func %v(writer io.Writer, codec container.Codec, recordsPerBlock int64, opts ...container.WriterOption) (*container.Writer, error) {
+	str := &%v{}
+	return container.NewWriter(writer, codec, recordsPerBlock, str.Schema(), opts...)
+}
+`
+
+const recordAppendWriterTemplate = `
+func %v(file io.ReadWriteSeeker, recordsPerBlock int64, opts ...container.WriterOption) (*container.Writer, error) {
 	str := &%v{}
-	return container.NewWriter(writer, codec, recordsPerBlock, str.Schema())
+	return container.NewAppendWriter(file, recordsPerBlock, str.Schema(), opts...)
+}
+`
+
+const recordRollingWriterTemplate = `
+func %v(factory container.FileFactory, codec container.Codec, recordsPerBlock int64, opts ...container.RollingOption) (*container.RollingWriter, error) {
+	str := &%v{}
+	return container.NewRollingWriter(factory, codec, recordsPerBlock, str.Schema(), opts...)
+}
+`
+
+const recordReaderStructTemplate = `
+// %v reads %v records one at a time from an Avro object container file
+type %v struct {
+	reader *container.Reader
+	// Converts records to this record's schema, if the file was written with a different schema
+	resolver *schema.Resolver
+	record   %v
+	err      error
+}
+`
+
+const recordReaderTemplate = `
+func %v(reader io.Reader) (*%v, error) {
+	containerReader, err := container.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	str := &%v{}
+	err = containerReader.CheckSchema(str.Schema())
+	if err == nil {
+		return &%v{reader: containerReader}, nil
+	}
+
+	// Files written with a different schema can still be read if their schema can be resolved to this one
+	resolver, resolveErr := schema.CachedResolver(containerReader.Schema(), str.Schema())
+	if resolveErr != nil {
+		return nil, err
+	}
+	return &%v{reader: containerReader, resolver: resolver}, nil
+}
+`
+
+const recordReaderNextTemplate = `
+// Read the next record from the file. Returns false when there are no records left or an error occurs, use Err to distinguish the two cases.
+func (r *%v) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	datumReader, err := r.reader.Next()
+	if err == nil && r.resolver != nil {
+		datumReader, err = r.resolver.TranscodeReader(datumReader)
+	}
+
+	if err != nil {
+		if err != io.EOF {
+			r.err = err
+		}
+		r.record = nil
+		return false
+	}
+
+	r.record, r.err = %v(datumReader)
+	return r.err == nil
+}
+`
+
+const recordReaderRecordTemplate = `
+// The record read by the most recent call to Next
+func (r *%v) Record() %v {
+	return r.record
+}
+`
+
+const recordReaderErrTemplate = `
+// The first error encountered while reading the file, or nil if the file was read successfully
+func (r *%v) Err() error {
+	return r.err
 }
 `
 
@@ -135,6 +313,22 @@ func (r *RecordDefinition) publicDeserializerMethod() string {
 	return fmt.Sprintf("Deserialize%v", r.Name())
 }
 
+func (r *RecordDefinition) fromSchemaDeserializerMethod() string {
+	return fmt.Sprintf("Deserialize%vFromSchema", r.Name())
+}
+
+func (r *RecordDefinition) fromSchemaDeserializerMethodDef() string {
+	return fmt.Sprintf(recordStructFromSchemaDeserializerTemplate, r.Name(), r.Name(), r.fromSchemaDeserializerMethod(), r.GoType(), r.Name(), r.DeserializerMethod(), r.DeserializerMethod())
+}
+
+func (r *RecordDefinition) unmarshalSingleObjectMethod() string {
+	return fmt.Sprintf("Unmarshal%vSingleObject", r.Name())
+}
+
+func (r *RecordDefinition) unmarshalSingleObjectMethodDef() string {
+	return fmt.Sprintf(recordUnmarshalSingleObjectTemplate, r.Name(), r.Name(), r.unmarshalSingleObjectMethod(), r.GoType(), r.Name(), r.DeserializerMethod(), r.fromSchemaDeserializerMethod())
+}
+
 func (r *RecordDefinition) recordWriterMethod() string {
 	return fmt.Sprintf("New%vWriter", r.Name())
 }
@@ -143,6 +337,47 @@ func (r *RecordDefinition) recordWriterMethodDef() string {
 	return fmt.Sprintf(recordWriterTemplate, r.recordWriterMethod(), r.Name())
 }
 
+func (r *RecordDefinition) recordAppendWriterMethod() string {
+	return fmt.Sprintf("New%vAppendWriter", r.Name())
+}
+
+func (r *RecordDefinition) recordAppendWriterMethodDef() string {
+	return fmt.Sprintf(recordAppendWriterTemplate, r.recordAppendWriterMethod(), r.Name())
+}
+
+func (r *RecordDefinition) recordRollingWriterMethod() string {
+	return fmt.Sprintf("New%vRollingWriter", r.Name())
+}
+
+func (r *RecordDefinition) recordRollingWriterMethodDef() string {
+	return fmt.Sprintf(recordRollingWriterTemplate, r.recordRollingWriterMethod(), r.Name())
+}
+
+func (r *RecordDefinition) recordReaderType() string {
+	return fmt.Sprintf("%vReader", r.Name())
+}
+
+func (r *RecordDefinition) recordReaderMethod() string {
+	return fmt.Sprintf("New%vReader", r.Name())
+}
+
+func (r *RecordDefinition) recordReaderStructDef() string {
+	return fmt.Sprintf(recordReaderStructTemplate, r.recordReaderType(), r.Name(), r.recordReaderType(), r.GoType())
+}
+
+func (r *RecordDefinition) recordReaderMethodDef() string {
+	return fmt.Sprintf(recordReaderTemplate, r.recordReaderMethod(), r.recordReaderType(), r.Name(), r.recordReaderType(), r.recordReaderType())
+}
+
+func (r *RecordDefinition) addRecordReader(p *generator.Package) {
+	readerType := "*" + r.recordReaderType()
+	p.AddStruct(r.filename(), r.recordReaderType(), r.recordReaderStructDef())
+	p.AddFunction(r.filename(), "", r.recordReaderMethod(), r.recordReaderMethodDef())
+	p.AddFunction(r.filename(), readerType, "Next", fmt.Sprintf(recordReaderNextTemplate, r.recordReaderType(), r.DeserializerMethod()))
+	p.AddFunction(r.filename(), readerType, "Record", fmt.Sprintf(recordReaderRecordTemplate, r.recordReaderType(), r.GoType()))
+	p.AddFunction(r.filename(), readerType, "Err", fmt.Sprintf(recordReaderErrTemplate, r.recordReaderType()))
+}
+
 func (r *RecordDefinition) publicSerializerMethodDef() string {
 	return fmt.Sprintf(recordStructPublicSerializerTemplate, r.GoType(), r.SerializerMethod())
 }
@@ -165,6 +400,31 @@ func (r *RecordDefinition) schemaMethodDef() (string, error) {
 	return fmt.Sprintf(recordSchemaTemplate, r.GoType(), strconv.Quote(string(schemaJson))), nil
 }
 
+// Generate methods returning the schema's canonical form and fingerprints, which are calculated once here
+func (r *RecordDefinition) addFingerprintMethods(p *generator.Package) error {
+	var buf bytes.Buffer
+	if err := appendCanonicalDefinition(&buf, r, make(map[QualifiedName]bool)); err != nil {
+		return err
+	}
+
+	canonical := buf.Bytes()
+	md5 := FingerprintMD5(canonical)
+	sha256 := FingerprintSHA256(canonical)
+	p.AddFunction(r.filename(), r.GoType(), "CanonicalSchema", fmt.Sprintf(recordCanonicalSchemaTemplate, r.GoType(), strconv.Quote(string(canonical))))
+	p.AddFunction(r.filename(), r.GoType(), "Fingerprint64", fmt.Sprintf(recordFingerprint64Template, r.GoType(), Fingerprint64(canonical)))
+	p.AddFunction(r.filename(), r.GoType(), "FingerprintMD5", fmt.Sprintf(recordFingerprintMD5Template, r.GoType(), byteArrayLiteral(md5[:])))
+	p.AddFunction(r.filename(), r.GoType(), "FingerprintSHA256", fmt.Sprintf(recordFingerprintSHA256Template, r.GoType(), byteArrayLiteral(sha256[:])))
+	return nil
+}
+
+func byteArrayLiteral(bb []byte) string {
+	items := make([]string, len(bb))
+	for i, b := range bb {
+		items[i] = fmt.Sprintf("%#02x", b)
+	}
+	return strings.Join(items, ", ")
+}
+
 func (r *RecordDefinition) AddStruct(p *generator.Package, containers bool) error {
 	// Import guard, to avoid circular dependencies
 	if !p.HasStruct(r.filename(), r.GoType()) {
@@ -175,6 +435,10 @@ func (r *RecordDefinition) AddStruct(p *generator.Package, containers bool) erro
 		}
 
 		p.AddFunction(r.filename(), r.GoType(), "Schema", schemaDef)
+		if err = r.addFingerprintMethods(p); err != nil {
+			return err
+		}
+
 		constructorMethodDef, err := r.ConstructorMethodDef()
 		if err != nil {
 			return err
@@ -182,7 +446,11 @@ func (r *RecordDefinition) AddStruct(p *generator.Package, containers bool) erro
 
 		if containers {
 			p.AddImport(r.filename(), "github.com/actgardner/gogen-avro/container")
+			p.AddImport(r.filename(), "github.com/actgardner/gogen-avro/schema")
 			p.AddFunction(r.filename(), "", r.recordWriterMethod(), r.recordWriterMethodDef())
+			p.AddFunction(r.filename(), "", r.recordAppendWriterMethod(), r.recordAppendWriterMethodDef())
+			p.AddFunction(r.filename(), "", r.recordRollingWriterMethod(), r.recordRollingWriterMethodDef())
+			r.addRecordReader(p)
 		}
 
 		p.AddFunction(r.filename(), r.GoType(), r.ConstructorMethod(), constructorMethodDef)
@@ -217,6 +485,21 @@ func (r *RecordDefinition) AddDeserializer(p *generator.Package) {
 	}
 }
 
+// Add Deserialize<RecordType>FromSchema, which reads records written with a different schema
+func (r *RecordDefinition) addFromSchemaDeserializer(p *generator.Package) {
+	p.AddImport(r.filename(), "github.com/actgardner/gogen-avro/schema")
+	p.AddFunction(r.filename(), "", r.fromSchemaDeserializerMethod(), r.fromSchemaDeserializerMethodDef())
+}
+
+// Add the methods for the single-object encoding, which read messages written with other schemas with Deserialize<RecordType>FromSchema
+func (r *RecordDefinition) addSingleObjectMethods(p *generator.Package) {
+	r.addFromSchemaDeserializer(p)
+	p.AddImport(r.filename(), "bytes")
+	p.AddImport(r.filename(), "github.com/actgardner/gogen-avro/soe")
+	p.AddFunction(r.filename(), r.GoType(), "MarshalSingleObject", fmt.Sprintf(recordMarshalSingleObjectTemplate, r.GoType()))
+	p.AddFunction(r.filename(), "", r.unmarshalSingleObjectMethod(), r.unmarshalSingleObjectMethodDef())
+}
+
 func (r *RecordDefinition) ResolveReferences(n *Namespace) error {
 	var err error
 	for _, f := range r.fields {
@@ -288,6 +571,10 @@ func (r *RecordDefinition) ConstructorMethodDef() (string, error) {
 	return fmt.Sprintf(recordConstructorTemplate, r.ConstructorMethod(), r.GoType(), r.Name(), fieldConstructors, defaults), nil
 }
 
+func (r *RecordDefinition) Fields() []*Field {
+	return r.fields
+}
+
 func (r *RecordDefinition) FieldByName(name string) *Field {
 	for _, f := range r.fields {
 		if f.Name() == name {
@@ -20,6 +20,11 @@ func NewReference(typeName QualifiedName) *Reference {
 	}
 }
 
+// The definition the reference refers to, once ResolveReferences has been called
+func (s *Reference) ResolvedDefinition() Definition {
+	return s.def
+}
+
 func (s *Reference) Name() string {
 	return s.def.Name()
 }
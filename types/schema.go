@@ -48,7 +48,9 @@ func NewNamespace(shortUnions bool, definitionCompareOnlyName bool) *Namespace {
 	}
 }
 
-func (namespace *Namespace) AddToPackage(p *generator.Package, headerComment string, containers bool) error {
+// Generate the code for every schema in the namespace. The methods for containers, reading records written with another
+// schema and the single-object encoding are only generated when they're enabled, since they import gogen-avro's runtime packages.
+func (namespace *Namespace) AddToPackage(p *generator.Package, headerComment string, containers, fromSchema, singleObject bool) error {
 	for _, schema := range namespace.Schemas {
 		err := schema.Root.ResolveReferences(namespace)
 		if err != nil {
@@ -60,15 +62,31 @@ func (namespace *Namespace) AddToPackage(p *generator.Package, headerComment str
 		schema.Root.AddDeserializer(p)
 	}
 
+	for _, definition := range namespace.Definitions {
+		record, ok := definition.(*RecordDefinition)
+		if !ok {
+			continue
+		}
+
+		if fromSchema {
+			record.addFromSchemaDeserializer(p)
+		}
+		if singleObject {
+			record.addSingleObjectMethods(p)
+		}
+	}
+
 	for _, f := range p.Files() {
 		p.AddHeader(f, headerComment)
 	}
 	return nil
 }
 
-// Add a new type definition to the namespace. Returns an error if the type is already defined.
+// Add a new type definition to the namespace. Returns an error if the type is already defined differently,
+// or if one of its aliases is the name or alias of another type.
 func (n *Namespace) RegisterDefinition(d Definition) error {
-	if curDef, ok := n.Definitions[d.AvroName()]; ok {
+	curDef, redefined := n.Definitions[d.AvroName()]
+	if redefined {
 		if n.DefinitionCompareOnlyName {
 			if !reflect.DeepEqual(curDef.AvroName(), d.AvroName()) && !reflect.DeepEqual(curDef.Aliases(), d.Aliases()) {
 				diffs := pretty.Diff(curDef, d)
@@ -90,7 +108,8 @@ func (n *Namespace) RegisterDefinition(d Definition) error {
 	n.Definitions[d.AvroName()] = d
 
 	for _, alias := range d.Aliases() {
-		if _, ok := n.Definitions[alias]; ok {
+		// A repeated definition replaces the aliases of the first one
+		if aliasDef, ok := n.Definitions[alias]; ok && !(redefined && aliasDef == curDef) {
 			return fmt.Errorf("Conflicting alias for %v - %v", d.AvroName(), alias)
 		}
 		n.Definitions[alias] = d
@@ -130,6 +149,22 @@ func (n *Namespace) TypeForSchema(schemaJson []byte) (AvroType, error) {
 	return field, nil
 }
 
+// Parse a single schema in a new Namespace and resolve the references to named types within it, for code which
+// uses schemas at runtime rather than generating code for them
+func ParseSchema(schemaJson []byte) (AvroType, error) {
+	namespace := NewNamespace(false, false)
+	avroType, err := namespace.TypeForSchema(schemaJson)
+	if err != nil {
+		return nil, err
+	}
+
+	err = avroType.ResolveReferences(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return avroType, nil
+}
+
 func (n *Namespace) decodeTypeDefinition(name, namespace string, schema interface{}) (AvroType, error) {
 	switch schema.(type) {
 	case string:
@@ -49,6 +49,14 @@ func NewUnionField(name string, itemType []AvroType, definition []interface{}) *
 	}
 }
 
+// The types of the union's branches, if avroType is a union
+func UnionItemTypes(avroType AvroType) ([]AvroType, bool) {
+	if union, ok := avroType.(*unionField); ok {
+		return union.itemType, true
+	}
+	return nil, false
+}
+
 func (s *unionField) compositeFieldName() string {
 	var unionFields = "Union"
 	for _, i := range s.itemType {